@@ -2,6 +2,7 @@ package conv
 
 import (
 	"fmt"
+	"reflect"
 	"time"
 )
 
@@ -110,8 +111,28 @@ func ToAnyE[T any](a any) (T, error) {
 			return t, err
 		}
 		t = any(v).(T)
+	case time.Time:
+		v, err := ToTimeE(a)
+		if err != nil {
+			return t, err
+		}
+		t = any(v).(T)
 	default:
-		return t, fmt.Errorf("the type %T isn't supported", t)
+		// slices, maps and named types
+		typ := reflect.TypeOf(&t).Elem()
+		v, err := ToTypeE(a, typ)
+		if err != nil {
+			return t, err
+		}
+		if !v.IsValid() || (v.Kind() == reflect.Interface && v.IsNil()) {
+			// the zero value of an interface type
+			return t, nil
+		}
+		r, ok := v.Interface().(T)
+		if !ok {
+			return t, fmt.Errorf("unable to cast %#v of type %T to %s", a, a, typ)
+		}
+		t = r
 	}
 	return t, nil
 }
@@ -0,0 +1,188 @@
+package conv
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	bytesType    = reflect.TypeOf([]byte(nil))
+)
+
+// ToTypeE converts a to the type t, slices and maps are converted element by element.
+func ToTypeE(a any, t reflect.Type) (reflect.Value, error) {
+	if a != nil && reflect.TypeOf(a) == t {
+		return reflect.ValueOf(a), nil
+	}
+
+	out := reflect.New(t).Elem()
+	switch t {
+	case timeType:
+		v, err := ToTimeE(a)
+		if err != nil {
+			return out, err
+		}
+		out.Set(reflect.ValueOf(v))
+		return out, nil
+	case durationType:
+		v, err := ToDurationE(a)
+		if err != nil {
+			return out, err
+		}
+		out.SetInt(int64(v))
+		return out, nil
+	case bytesType:
+		if s, ok := indirect(a).(string); ok {
+			out.SetBytes([]byte(s))
+			return out, nil
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		v, err := ToBoolE(a)
+		if err != nil {
+			return out, err
+		}
+		out.SetBool(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := ToInt64E(a)
+		if err != nil {
+			return out, err
+		}
+		if out.OverflowInt(v) {
+			return out, fmt.Errorf("unable to cast %#v of type %T to %s: overflow", a, a, t)
+		}
+		out.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v, err := ToUint64E(a)
+		if err != nil {
+			return out, err
+		}
+		if out.OverflowUint(v) {
+			return out, fmt.Errorf("unable to cast %#v of type %T to %s: overflow", a, a, t)
+		}
+		out.SetUint(v)
+	case reflect.Float32, reflect.Float64:
+		v, err := ToFloat64E(a)
+		if err != nil {
+			return out, err
+		}
+		if out.OverflowFloat(v) {
+			return out, fmt.Errorf("unable to cast %#v of type %T to %s: overflow", a, a, t)
+		}
+		out.SetFloat(v)
+	case reflect.String:
+		v, err := ToStringE(a)
+		if err != nil {
+			return out, err
+		}
+		out.SetString(v)
+	case reflect.Interface:
+		if a == nil {
+			return out, nil
+		}
+		v := reflect.ValueOf(a)
+		if !v.Type().Implements(t) {
+			return out, fmt.Errorf("unable to cast %#v of type %T to %s", a, a, t)
+		}
+		out.Set(v)
+	case reflect.Slice:
+		return toSliceValue(a, t)
+	case reflect.Map:
+		return toMapValue(a, t)
+	case reflect.Pointer:
+		if a == nil {
+			return out, nil
+		}
+		v, err := ToTypeE(indirect(a), t.Elem())
+		if err != nil {
+			return out, err
+		}
+		p := reflect.New(t.Elem())
+		p.Elem().Set(v)
+		out.Set(p)
+	default:
+		return out, fmt.Errorf("the type %s isn't supported", t)
+	}
+	return out, nil
+}
+
+func toSliceValue(a any, t reflect.Type) (reflect.Value, error) {
+	a = indirect(a)
+	if a == nil {
+		return reflect.Zero(t), nil
+	}
+	if s, ok := a.(string); ok {
+		// a JSON array, otherwise whitespace separated values like ToSliceE
+		if strings.HasPrefix(strings.TrimSpace(s), "[") {
+			var vs []any
+			if err := jsonStringToObject(s, &vs); err != nil {
+				return reflect.Zero(t), err
+			}
+			a = vs
+		} else {
+			a = strings.Fields(s)
+		}
+	}
+
+	v := reflect.ValueOf(a)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		// a single value
+		elem, err := ToTypeE(a, t.Elem())
+		if err != nil {
+			return reflect.Zero(t), err
+		}
+		out := reflect.MakeSlice(t, 1, 1)
+		out.Index(0).Set(elem)
+		return out, nil
+	}
+
+	out := reflect.MakeSlice(t, v.Len(), v.Len())
+	for i := 0; i < v.Len(); i++ {
+		elem, err := ToTypeE(v.Index(i).Interface(), t.Elem())
+		if err != nil {
+			return reflect.Zero(t), err
+		}
+		out.Index(i).Set(elem)
+	}
+	return out, nil
+}
+
+func toMapValue(a any, t reflect.Type) (reflect.Value, error) {
+	a = indirect(a)
+	if a == nil {
+		return reflect.Zero(t), nil
+	}
+	if s, ok := a.(string); ok {
+		var m map[string]any
+		if err := jsonStringToObject(s, &m); err != nil {
+			return reflect.Zero(t), err
+		}
+		a = m
+	}
+
+	v := reflect.ValueOf(a)
+	if v.Kind() != reflect.Map {
+		return reflect.Zero(t), fmt.Errorf("unable to cast %#v of type %T to %s", a, a, t)
+	}
+
+	out := reflect.MakeMapWithSize(t, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		k, err := ToTypeE(iter.Key().Interface(), t.Key())
+		if err != nil {
+			return reflect.Zero(t), err
+		}
+		val, err := ToTypeE(iter.Value().Interface(), t.Elem())
+		if err != nil {
+			return reflect.Zero(t), err
+		}
+		out.SetMapIndex(k, val)
+	}
+	return out, nil
+}
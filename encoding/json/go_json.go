@@ -2,6 +2,12 @@ package json
 
 import "github.com/goccy/go-json"
 
+// RawMessage is exported by gin/json package.
+type RawMessage = json.RawMessage
+
+// Number is exported by gin/json package.
+type Number = json.Number
+
 var (
 	// Marshal is exported by gin/json package.
 	Marshal = json.Marshal
@@ -0,0 +1,230 @@
+package interceptor
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stage toggles a single interceptor stage.
+type Stage struct {
+	Enabled bool
+	// Skip full method names ("/pkg.Service/Method") the stage is bypassed for,
+	// an entry ending with '*' matches by prefix.
+	Skip []string
+}
+
+func (s Stage) skip(method string) bool {
+	for _, m := range s.Skip {
+		if strings.HasSuffix(m, "*") {
+			if strings.HasPrefix(method, strings.TrimSuffix(m, "*")) {
+				return true
+			}
+		} else if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Tracer starts a span for the rpc, the returned func ends it.
+type Tracer interface {
+	Start(ctx context.Context, method string) (context.Context, func(err error))
+}
+
+// MetricsRecorder observes the result of each rpc.
+type MetricsRecorder interface {
+	Observe(method string, code codes.Code, elapsed time.Duration)
+}
+
+// AuthFunc authenticates the server-side request, or attaches credentials to the
+// outgoing client request, and returns the context used by the next stage.
+type AuthFunc func(ctx context.Context, method string) (context.Context, error)
+
+// Config the interceptor chain, stages always run in the order
+// recovery, tracing, logging, metrics, auth, validation, error conversion.
+type Config struct {
+	Recovery   Stage
+	Tracing    Stage
+	Logging    Stage
+	Metrics    Stage
+	Auth       Stage
+	Validation Stage
+	Error      Stage
+
+	Tracer   Tracer
+	Recorder MetricsRecorder
+	AuthFunc AuthFunc
+}
+
+// ServerOptions returns the unary and stream server chains built from cfg.
+func ServerOptions(cfg Config) []grpc.ServerOption {
+	var (
+		unary  []grpc.UnaryServerInterceptor
+		stream []grpc.StreamServerInterceptor
+	)
+
+	if cfg.Recovery.Enabled {
+		unary = append(unary, skipUnaryServer(cfg.Recovery, ServerRecoveryInterceptor()))
+		stream = append(stream, skipStreamServer(cfg.Recovery, StreamServerRecoveryInterceptor()))
+	}
+	if cfg.Tracing.Enabled && cfg.Tracer != nil {
+		unary = append(unary, skipUnaryServer(cfg.Tracing, ServerTracingInterceptor(cfg.Tracer)))
+		stream = append(stream, skipStreamServer(cfg.Tracing, StreamServerTracingInterceptor(cfg.Tracer)))
+	}
+	if cfg.Logging.Enabled {
+		unary = append(unary, skipUnaryServer(cfg.Logging, ServerLoggingInterceptor()))
+		stream = append(stream, skipStreamServer(cfg.Logging, StreamServerLoggingInterceptor()))
+	}
+	if cfg.Metrics.Enabled && cfg.Recorder != nil {
+		unary = append(unary, skipUnaryServer(cfg.Metrics, ServerMetricsInterceptor(cfg.Recorder)))
+		stream = append(stream, skipStreamServer(cfg.Metrics, StreamServerMetricsInterceptor(cfg.Recorder)))
+	}
+	if cfg.Auth.Enabled && cfg.AuthFunc != nil {
+		unary = append(unary, skipUnaryServer(cfg.Auth, ServerAuthInterceptor(cfg.AuthFunc)))
+		stream = append(stream, skipStreamServer(cfg.Auth, StreamServerAuthInterceptor(cfg.AuthFunc)))
+	}
+	if cfg.Validation.Enabled {
+		unary = append(unary, skipUnaryServer(cfg.Validation, ServerValidationInterceptor()))
+		stream = append(stream, skipStreamServer(cfg.Validation, StreamServerValidationInterceptor()))
+	}
+	if cfg.Error.Enabled {
+		unary = append(unary, skipUnaryServer(cfg.Error, ServerErrorInterceptor()))
+		stream = append(stream, skipStreamServer(cfg.Error, StreamServerErrorInterceptor()))
+	}
+
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}
+}
+
+// DialOptions returns the unary and stream client chains built from cfg.
+func DialOptions(cfg Config) []grpc.DialOption {
+	var (
+		unary  []grpc.UnaryClientInterceptor
+		stream []grpc.StreamClientInterceptor
+	)
+
+	if cfg.Recovery.Enabled {
+		unary = append(unary, skipUnaryClient(cfg.Recovery, ClientRecoveryInterceptor()))
+		stream = append(stream, skipStreamClient(cfg.Recovery, StreamClientRecoveryInterceptor()))
+	}
+	if cfg.Tracing.Enabled && cfg.Tracer != nil {
+		unary = append(unary, skipUnaryClient(cfg.Tracing, ClientTracingInterceptor(cfg.Tracer)))
+		stream = append(stream, skipStreamClient(cfg.Tracing, StreamClientTracingInterceptor(cfg.Tracer)))
+	}
+	if cfg.Logging.Enabled {
+		unary = append(unary, skipUnaryClient(cfg.Logging, ClientLoggingInterceptor()))
+		stream = append(stream, skipStreamClient(cfg.Logging, StreamClientLoggingInterceptor()))
+	}
+	if cfg.Metrics.Enabled && cfg.Recorder != nil {
+		unary = append(unary, skipUnaryClient(cfg.Metrics, ClientMetricsInterceptor(cfg.Recorder)))
+		stream = append(stream, skipStreamClient(cfg.Metrics, StreamClientMetricsInterceptor(cfg.Recorder)))
+	}
+	if cfg.Auth.Enabled && cfg.AuthFunc != nil {
+		unary = append(unary, skipUnaryClient(cfg.Auth, ClientAuthInterceptor(cfg.AuthFunc)))
+		stream = append(stream, skipStreamClient(cfg.Auth, StreamClientAuthInterceptor(cfg.AuthFunc)))
+	}
+	if cfg.Validation.Enabled {
+		unary = append(unary, skipUnaryClient(cfg.Validation, ClientValidationInterceptor()))
+	}
+	if cfg.Error.Enabled {
+		unary = append(unary, skipUnaryClient(cfg.Error, ClientErrorInterceptor()))
+		stream = append(stream, skipStreamClient(cfg.Error, StreamClientErrorInterceptor()))
+	}
+
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(unary...),
+		grpc.WithChainStreamInterceptor(stream...),
+	}
+}
+
+func skipUnaryServer(s Stage, next grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	if len(s.Skip) == 0 {
+		return next
+	}
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if s.skip(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		return next(ctx, req, info, handler)
+	}
+}
+
+func skipStreamServer(s Stage, next grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	if len(s.Skip) == 0 {
+		return next
+	}
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if s.skip(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		return next(srv, ss, info, handler)
+	}
+}
+
+func skipUnaryClient(s Stage, next grpc.UnaryClientInterceptor) grpc.UnaryClientInterceptor {
+	if len(s.Skip) == 0 {
+		return next
+	}
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if s.skip(method) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		return next(ctx, method, req, reply, cc, invoker, opts...)
+	}
+}
+
+func skipStreamClient(s Stage, next grpc.StreamClientInterceptor) grpc.StreamClientInterceptor {
+	if len(s.Skip) == 0 {
+		return next
+	}
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if s.skip(method) {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+		return next(ctx, desc, cc, method, streamer, opts...)
+	}
+}
+
+// wrappedServerStream overrides the context of a grpc.ServerStream.
+type wrappedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (w *wrappedServerStream) Context() context.Context {
+	return w.ctx
+}
+
+// finishClientStream calls finish once, when the stream ends: on io.EOF or an error, or
+// after the single response of a stream whose server doesn't stream.
+type finishClientStream struct {
+	grpc.ClientStream
+	serverStreams bool
+	once          sync.Once
+	finish        func(err error)
+}
+
+func newFinishClientStream(cs grpc.ClientStream, desc *grpc.StreamDesc, finish func(err error)) grpc.ClientStream {
+	return &finishClientStream{ClientStream: cs, serverStreams: desc.ServerStreams, finish: finish}
+}
+
+func (s *finishClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == io.EOF {
+		s.once.Do(func() { s.finish(nil) })
+	} else if err != nil {
+		s.once.Do(func() { s.finish(err) })
+	} else if !s.serverStreams {
+		// a client streaming rpc receives one response, callers such as CloseAndRecv
+		// don't call RecvMsg again to see io.EOF
+		s.once.Do(func() { s.finish(nil) })
+	}
+	return err
+}
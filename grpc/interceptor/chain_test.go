@@ -0,0 +1,332 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"io"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testUnary        = "/test.Echo/Unary"
+	testClientStream = "/test.Echo/ClientStream"
+	testServerStream = "/test.Echo/ServerStream"
+)
+
+// testEcho serves the unary and streaming methods of test.Echo, handler is called by Unary.
+type testEcho struct {
+	handler func(ctx context.Context, req *wrapperspb.StringValue) (*wrapperspb.StringValue, error)
+}
+
+var testEchoDesc = grpc.ServiceDesc{
+	ServiceName: "test.Echo",
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Unary",
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			req := new(wrapperspb.StringValue)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.(*testEcho).unary(ctx, req.(*wrapperspb.StringValue))
+			}
+			if interceptor == nil {
+				return handler(ctx, req)
+			}
+			return interceptor(ctx, req, &grpc.UnaryServerInfo{Server: srv, FullMethod: testUnary}, handler)
+		},
+	}},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ClientStream",
+			ClientStreams: true,
+			Handler: func(_ interface{}, ss grpc.ServerStream) error {
+				var n int
+				for {
+					if err := ss.RecvMsg(new(wrapperspb.StringValue)); err == io.EOF {
+						break
+					} else if err != nil {
+						return err
+					}
+					n++
+				}
+				return ss.SendMsg(wrapperspb.Int32(int32(n)))
+			},
+		},
+		{
+			StreamName:    "ServerStream",
+			ServerStreams: true,
+			Handler: func(_ interface{}, ss grpc.ServerStream) error {
+				req := new(wrapperspb.StringValue)
+				if err := ss.RecvMsg(req); err != nil {
+					return err
+				}
+				for i := 0; i < 3; i++ {
+					if err := ss.SendMsg(req); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+	},
+}
+
+func (e *testEcho) unary(ctx context.Context, req *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+	if e.handler != nil {
+		return e.handler(ctx, req)
+	}
+	return req, nil
+}
+
+// startEcho serves echo over bufconn and returns a client connection dialed with dialOpts.
+func startEcho(t *testing.T, echo *testEcho, serverOpts []grpc.ServerOption, dialOpts ...grpc.DialOption) *grpc.ClientConn {
+	t.Helper()
+	ln := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(serverOpts...)
+	srv.RegisterService(&testEchoDesc, echo)
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(srv.Stop)
+
+	dialOpts = append(dialOpts,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	cc, err := grpc.Dial("bufnet", dialOpts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = cc.Close() })
+	return cc
+}
+
+// callRecorder records the stages an rpc passes through, it is the Tracer, the
+// MetricsRecorder and the AuthFunc of the test chains.
+type callRecorder struct {
+	mu     sync.Mutex
+	calls  []string
+	ended  map[string]error
+	codes  map[string]codes.Code
+	notify chan string
+}
+
+func newCallRecorder() *callRecorder {
+	return &callRecorder{ended: make(map[string]error), codes: make(map[string]codes.Code), notify: make(chan string, 16)}
+}
+
+func (r *callRecorder) add(call string) {
+	r.mu.Lock()
+	r.calls = append(r.calls, call)
+	r.mu.Unlock()
+}
+
+func (r *callRecorder) Start(ctx context.Context, method string) (context.Context, func(err error)) {
+	r.add("tracing " + method)
+	return ctx, func(err error) {
+		r.mu.Lock()
+		r.ended[method] = err
+		r.mu.Unlock()
+		r.notify <- method
+	}
+}
+
+func (r *callRecorder) Observe(method string, code codes.Code, _ time.Duration) {
+	r.mu.Lock()
+	r.codes[method] = code
+	r.mu.Unlock()
+}
+
+func (r *callRecorder) auth(ctx context.Context, method string) (context.Context, error) {
+	r.add("auth " + method)
+	return ctx, nil
+}
+
+func (r *callRecorder) snapshot() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.calls)
+}
+
+func (r *callRecorder) waitEnd(t *testing.T, method string) error {
+	t.Helper()
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case m := <-r.notify:
+			if m == method {
+				r.mu.Lock()
+				defer r.mu.Unlock()
+				return r.ended[method]
+			}
+		case <-timeout:
+			t.Fatalf("%s was not finished", method)
+			return nil
+		}
+	}
+}
+
+func testChainConfig(r *callRecorder) Config {
+	return Config{
+		Recovery: Stage{Enabled: true},
+		Tracing:  Stage{Enabled: true},
+		Metrics:  Stage{Enabled: true},
+		Auth:     Stage{Enabled: true, Skip: []string{"/test.Echo/Server*"}},
+		Error:    Stage{Enabled: true},
+		Tracer:   r,
+		Recorder: r,
+		AuthFunc: r.auth,
+	}
+}
+
+func TestServerChainOrderAndSkip(t *testing.T) {
+	r := newCallRecorder()
+	echo := &testEcho{handler: func(ctx context.Context, req *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+		r.add("handler")
+		return req, nil
+	}}
+	cc := startEcho(t, echo, ServerOptions(testChainConfig(r)))
+
+	out := new(wrapperspb.StringValue)
+	if err := cc.Invoke(context.Background(), testUnary, wrapperspb.String("hi"), out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Value != "hi" {
+		t.Fatalf("reply = %q", out.Value)
+	}
+	want := []string{"tracing " + testUnary, "auth " + testUnary, "handler"}
+	if got := r.snapshot(); !slices.Equal(got, want) {
+		t.Fatalf("calls = %v, want %v", got, want)
+	}
+
+	// auth is skipped for the server stream
+	stream, err := cc.NewStream(context.Background(), &testEchoDesc.Streams[1], testServerStream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = stream.SendMsg(wrapperspb.String("hi")); err != nil {
+		t.Fatal(err)
+	}
+	if err = stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	for err == nil {
+		err = stream.RecvMsg(new(wrapperspb.StringValue))
+	}
+	if err != io.EOF {
+		t.Fatal(err)
+	}
+	if err = r.waitEnd(t, testServerStream); err != nil {
+		t.Fatal(err)
+	}
+	for _, call := range r.snapshot() {
+		if call == "auth "+testServerStream {
+			t.Fatalf("auth ran for a skipped method: %v", r.snapshot())
+		}
+	}
+}
+
+func TestServerChainRecoversPanic(t *testing.T) {
+	r := newCallRecorder()
+	echo := &testEcho{handler: func(context.Context, *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+		panic("boom")
+	}}
+	cc := startEcho(t, echo, ServerOptions(testChainConfig(r)))
+
+	err := cc.Invoke(context.Background(), testUnary, wrapperspb.String("hi"), new(wrapperspb.StringValue))
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("code = %v, want Internal: %v", status.Code(err), err)
+	}
+	// recovery is the outermost stage, the span still ends while the panic unwinds
+	r.waitEnd(t, testUnary)
+}
+
+func TestClientChainFinishesStreams(t *testing.T) {
+	r := newCallRecorder()
+	cc := startEcho(t, &testEcho{}, nil, DialOptions(testChainConfig(r))...)
+
+	t.Run("client stream", func(t *testing.T) {
+		stream, err := cc.NewStream(context.Background(), &testEchoDesc.Streams[0], testClientStream)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 3; i++ {
+			if err = stream.SendMsg(wrapperspb.String("hi")); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// CloseAndRecv, the response is received once and RecvMsg isn't called again
+		if err = stream.CloseSend(); err != nil {
+			t.Fatal(err)
+		}
+		n := new(wrapperspb.Int32Value)
+		if err = stream.RecvMsg(n); err != nil {
+			t.Fatal(err)
+		}
+		if n.Value != 3 {
+			t.Fatalf("count = %d, want 3", n.Value)
+		}
+		if err = r.waitEnd(t, testClientStream); err != nil {
+			t.Fatalf("finished with %v", err)
+		}
+	})
+
+	t.Run("server stream", func(t *testing.T) {
+		stream, err := cc.NewStream(context.Background(), &testEchoDesc.Streams[1], testServerStream)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = stream.SendMsg(wrapperspb.String("hi")); err != nil {
+			t.Fatal(err)
+		}
+		if err = stream.CloseSend(); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 3; i++ {
+			if err = stream.RecvMsg(new(wrapperspb.StringValue)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		select {
+		case m := <-r.notify:
+			t.Fatalf("%s finished before io.EOF", m)
+		default:
+		}
+		if err = stream.RecvMsg(new(wrapperspb.StringValue)); err != io.EOF {
+			t.Fatalf("err = %v, want io.EOF", err)
+		}
+		if err = r.waitEnd(t, testServerStream); err != nil {
+			t.Fatalf("finished with %v", err)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		stream, err := cc.NewStream(ctx, &testEchoDesc.Streams[1], testServerStream)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cancel()
+		if err = stream.RecvMsg(new(wrapperspb.StringValue)); err == nil {
+			t.Fatal("expected an error after cancel")
+		}
+		if err = r.waitEnd(t, testServerStream); !errors.Is(err, context.Canceled) && status.Code(err) != codes.Canceled {
+			t.Fatalf("finished with %v, want canceled", err)
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if c := r.codes[testServerStream]; c != codes.Canceled {
+			t.Fatalf("observed %v, want Canceled", c)
+		}
+	})
+}
@@ -0,0 +1,273 @@
+package interceptor
+
+import (
+	"context"
+	"fmt"
+	"github.com/metaitself/xmeta/logger"
+	"github.com/metaitself/xmeta/metaerror"
+	"google.golang.org/grpc"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// BreakerState the state of a circuit breaker.
+type BreakerState int
+
+const (
+	StateClosed BreakerState = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("unknown(%d)", int(s))
+}
+
+// ReasonCircuitOpen is the reason of the error returned while a circuit is open.
+const ReasonCircuitOpen = "CIRCUIT_OPEN"
+
+// BreakerOption custom setup circuit breaker config
+type BreakerOption func(*breakerOption)
+
+type breakerOption struct {
+	consecutiveFailures int
+	failureRatio        float64
+	minRequests         int
+	interval            time.Duration
+	cooldown            time.Duration
+	halfOpenRequests    int
+	perMethod           bool
+	isFailure           func(err error) bool
+	// now the clock, replaced by tests
+	now func() time.Time
+}
+
+// WithConsecutiveFailures trips the circuit after n failures in a row, 0 disables the policy.
+func WithConsecutiveFailures(n int) BreakerOption {
+	return func(opt *breakerOption) {
+		opt.consecutiveFailures = n
+	}
+}
+
+// WithFailureRatio trips the circuit when the failure ratio within an interval
+// reaches ratio, once at least minRequests were observed.
+func WithFailureRatio(ratio float64, minRequests int) BreakerOption {
+	return func(opt *breakerOption) {
+		opt.failureRatio = ratio
+		opt.minRequests = minRequests
+	}
+}
+
+// WithBreakerInterval the period after which the closed state counters are cleared.
+func WithBreakerInterval(d time.Duration) BreakerOption {
+	return func(opt *breakerOption) {
+		opt.interval = d
+	}
+}
+
+// WithCooldown how long the circuit stays open before probing with half-open requests.
+func WithCooldown(d time.Duration) BreakerOption {
+	return func(opt *breakerOption) {
+		opt.cooldown = d
+	}
+}
+
+// WithHalfOpenRequests the number of probe requests allowed while half-open, the circuit
+// closes once all of them succeed and opens again on the first failure.
+func WithHalfOpenRequests(n int) BreakerOption {
+	return func(opt *breakerOption) {
+		opt.halfOpenRequests = n
+	}
+}
+
+// WithPerMethod keeps one circuit per target and method instead of one per target.
+func WithPerMethod(enabled bool) BreakerOption {
+	return func(opt *breakerOption) {
+		opt.perMethod = enabled
+	}
+}
+
+// WithFailureClassifier decides which errors count as failures.
+func WithFailureClassifier(f func(err error) bool) BreakerOption {
+	return func(opt *breakerOption) {
+		opt.isFailure = f
+	}
+}
+
+// isServerFailure counts only server-side errors, so business errors don't trip the circuit.
+func isServerFailure(err error) bool {
+	switch metaerror.FromError(err).Status {
+	case http.StatusInternalServerError,
+		http.StatusNotImplemented,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// CircuitBreakerInterceptor fails fast with a ServiceUnavailable error while the
+// circuit of the target (or target and method) is open.
+func CircuitBreakerInterceptor(opts ...BreakerOption) grpc.UnaryClientInterceptor {
+	opt := &breakerOption{
+		consecutiveFailures: 5,
+		interval:            time.Minute,
+		cooldown:            30 * time.Second,
+		halfOpenRequests:    1,
+		perMethod:           true,
+		isFailure:           isServerFailure,
+		now:                 time.Now,
+	}
+	for _, f := range opts {
+		f(opt)
+	}
+
+	var breakers sync.Map
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		name := cc.Target()
+		if opt.perMethod {
+			name += method
+		}
+		v, ok := breakers.Load(name)
+		if !ok {
+			v, _ = breakers.LoadOrStore(name, newCircuitBreaker(name, opt))
+		}
+		cb := v.(*circuitBreaker)
+
+		generation, err := cb.allow()
+		if err != nil {
+			return err
+		}
+		err = invoker(ctx, method, req, reply, cc, opts...)
+		cb.done(generation, err == nil || !opt.isFailure(err))
+		return err
+	}
+}
+
+type circuitBreaker struct {
+	name string
+	opt  *breakerOption
+
+	mu          sync.Mutex
+	state       BreakerState
+	generation  uint64
+	expiry      time.Time
+	requests    int
+	successes   int
+	failures    int
+	consecutive int
+}
+
+func newCircuitBreaker(name string, opt *breakerOption) *circuitBreaker {
+	cb := &circuitBreaker{name: name, opt: opt}
+	cb.toState(StateClosed, opt.now())
+	return cb
+}
+
+func (cb *circuitBreaker) allow() (uint64, error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := cb.opt.now()
+	cb.refresh(now)
+
+	switch cb.state {
+	case StateOpen:
+		return 0, metaerror.ServiceUnavailable(http.StatusServiceUnavailable, ReasonCircuitOpen,
+			fmt.Sprintf("circuit breaker %s is open", cb.name))
+	case StateHalfOpen:
+		if cb.requests >= cb.opt.halfOpenRequests {
+			return 0, metaerror.ServiceUnavailable(http.StatusServiceUnavailable, ReasonCircuitOpen,
+				fmt.Sprintf("circuit breaker %s is half-open", cb.name))
+		}
+	}
+	cb.requests++
+	return cb.generation, nil
+}
+
+func (cb *circuitBreaker) done(generation uint64, success bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := cb.opt.now()
+	cb.refresh(now)
+	// the result belongs to a previous state, ignore it
+	if generation != cb.generation {
+		return
+	}
+
+	if success {
+		cb.successes++
+		cb.consecutive = 0
+		if cb.state == StateHalfOpen && cb.successes >= cb.opt.halfOpenRequests {
+			cb.toState(StateClosed, now)
+		}
+		return
+	}
+
+	cb.failures++
+	cb.consecutive++
+	if cb.state == StateHalfOpen || cb.shouldTrip() {
+		cb.toState(StateOpen, now)
+	}
+}
+
+func (cb *circuitBreaker) shouldTrip() bool {
+	if cb.opt.consecutiveFailures > 0 && cb.consecutive >= cb.opt.consecutiveFailures {
+		return true
+	}
+	if cb.opt.failureRatio > 0 && cb.requests >= cb.opt.minRequests {
+		return float64(cb.failures)/float64(cb.requests) >= cb.opt.failureRatio
+	}
+	return false
+}
+
+// refresh moves an expired open circuit to half-open and clears the closed counters per interval.
+func (cb *circuitBreaker) refresh(now time.Time) {
+	if cb.expiry.IsZero() || now.Before(cb.expiry) {
+		return
+	}
+	switch cb.state {
+	case StateClosed:
+		cb.toState(StateClosed, now)
+	case StateOpen:
+		cb.toState(StateHalfOpen, now)
+	}
+}
+
+func (cb *circuitBreaker) toState(state BreakerState, now time.Time) {
+	prev := cb.state
+	cb.state = state
+	cb.generation++
+	cb.requests, cb.successes, cb.failures, cb.consecutive = 0, 0, 0, 0
+
+	switch state {
+	case StateClosed:
+		cb.expiry = time.Time{}
+		if cb.opt.interval > 0 {
+			cb.expiry = now.Add(cb.opt.interval)
+		}
+	case StateOpen:
+		cb.expiry = now.Add(cb.opt.cooldown)
+	case StateHalfOpen:
+		cb.expiry = time.Time{}
+	}
+
+	if prev != state {
+		logger.Warn("circuit breaker state changed",
+			logger.String("name", cb.name),
+			logger.String("from", prev.String()),
+			logger.String("to", state.String()),
+		)
+	}
+}
@@ -0,0 +1,205 @@
+package interceptor
+
+import (
+	"context"
+	"github.com/metaitself/xmeta/metaerror"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+func newTestBreaker(opts ...BreakerOption) (*circuitBreaker, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	opt := &breakerOption{
+		consecutiveFailures: 3,
+		interval:            time.Minute,
+		cooldown:            10 * time.Second,
+		halfOpenRequests:    1,
+		now:                 clock.now,
+	}
+	for _, f := range opts {
+		f(opt)
+	}
+	return newCircuitBreaker("test", opt), clock
+}
+
+// call runs one request through cb, it reports whether the request was allowed.
+func (cb *circuitBreaker) call(success bool) bool {
+	generation, err := cb.allow()
+	if err != nil {
+		return false
+	}
+	cb.done(generation, success)
+	return true
+}
+
+func TestBreakerTripsOnConsecutiveFailures(t *testing.T) {
+	cb, _ := newTestBreaker()
+	cb.call(false)
+	cb.call(false)
+	// a success resets the consecutive failures
+	cb.call(true)
+	cb.call(false)
+	cb.call(false)
+	if cb.state != StateClosed {
+		t.Fatalf("state = %v, want closed", cb.state)
+	}
+	cb.call(false)
+	if cb.state != StateOpen {
+		t.Fatalf("state = %v, want open", cb.state)
+	}
+
+	_, err := cb.allow()
+	if me := metaerror.FromError(err); me.Status != http.StatusServiceUnavailable || me.Reason != ReasonCircuitOpen {
+		t.Fatalf("allow = %v, want a circuit open error", err)
+	}
+}
+
+func TestBreakerTripsOnFailureRatio(t *testing.T) {
+	cb, _ := newTestBreaker(WithConsecutiveFailures(0), WithFailureRatio(0.5, 4))
+	cb.call(false)
+	cb.call(true)
+	cb.call(false)
+	if cb.state != StateClosed {
+		t.Fatalf("state = %v, want closed below the minimum requests", cb.state)
+	}
+	cb.call(true)
+	cb.call(false)
+	if cb.state != StateOpen {
+		t.Fatalf("state = %v, want open at 3/5 failures", cb.state)
+	}
+}
+
+func TestBreakerIntervalClearsCounters(t *testing.T) {
+	cb, clock := newTestBreaker()
+	cb.call(false)
+	cb.call(false)
+	clock.advance(time.Minute)
+	cb.call(false)
+	if cb.state != StateClosed {
+		t.Fatalf("state = %v, want closed after the interval cleared the failures", cb.state)
+	}
+}
+
+func TestBreakerCooldownAndHalfOpen(t *testing.T) {
+	cb, clock := newTestBreaker(WithConsecutiveFailures(1), WithHalfOpenRequests(2))
+	cb.call(false)
+
+	clock.advance(9 * time.Second)
+	if cb.call(true) {
+		t.Fatal("allowed during the cooldown")
+	}
+	clock.advance(time.Second)
+
+	g1, err := cb.allow()
+	if err != nil {
+		t.Fatalf("probe 1: %v", err)
+	}
+	if cb.state != StateHalfOpen {
+		t.Fatalf("state = %v, want half-open", cb.state)
+	}
+	g2, err := cb.allow()
+	if err != nil {
+		t.Fatalf("probe 2: %v", err)
+	}
+	if _, err = cb.allow(); err == nil {
+		t.Fatal("allowed more than the half-open requests")
+	}
+
+	cb.done(g1, true)
+	if cb.state != StateHalfOpen {
+		t.Fatalf("state = %v, want half-open until every probe succeeded", cb.state)
+	}
+	cb.done(g2, true)
+	if cb.state != StateClosed {
+		t.Fatalf("state = %v, want closed", cb.state)
+	}
+}
+
+func TestBreakerHalfOpenFailureReopens(t *testing.T) {
+	cb, clock := newTestBreaker(WithConsecutiveFailures(1), WithHalfOpenRequests(2))
+	cb.call(false)
+	clock.advance(10 * time.Second)
+
+	cb.call(true)
+	cb.call(false)
+	if cb.state != StateOpen {
+		t.Fatalf("state = %v, want open after a failed probe", cb.state)
+	}
+	if cb.call(true) {
+		t.Fatal("allowed right after reopening")
+	}
+}
+
+func TestBreakerIgnoresStaleGenerations(t *testing.T) {
+	cb, clock := newTestBreaker(WithConsecutiveFailures(1))
+	stale, err := cb.allow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cb.call(false)
+	clock.advance(10 * time.Second)
+
+	probe, err := cb.allow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a slow request from the closed state doesn't decide the half-open state
+	cb.done(stale, true)
+	if cb.state != StateHalfOpen {
+		t.Fatalf("state = %v, want half-open", cb.state)
+	}
+	cb.done(probe, true)
+	if cb.state != StateClosed {
+		t.Fatalf("state = %v, want closed", cb.state)
+	}
+}
+
+func TestCircuitBreakerInterceptor(t *testing.T) {
+	cc, err := grpc.Dial("passthrough:///breaker", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	interceptor := CircuitBreakerInterceptor(WithConsecutiveFailures(2))
+	var calls int
+	invoke := func(method string, fail error) error {
+		return interceptor(context.Background(), method, nil, nil, cc,
+			func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+				calls++
+				return fail
+			})
+	}
+
+	// business errors don't count as failures
+	for i := 0; i < 3; i++ {
+		_ = invoke("/a", metaerror.BadRequest(http.StatusBadRequest, "BAD", "bad"))
+	}
+	unavailable := metaerror.ServiceUnavailable(http.StatusServiceUnavailable, "DOWN", "down")
+	_ = invoke("/a", unavailable)
+	_ = invoke("/a", unavailable)
+	calls = 0
+	err = invoke("/a", nil)
+	if me := metaerror.FromError(err); me.Reason != ReasonCircuitOpen || calls != 0 {
+		t.Fatalf("err = %v, calls = %d, want a circuit open error without calling", err, calls)
+	}
+	// circuits are per method by default
+	if err = invoke("/b", nil); err != nil || calls != 1 {
+		t.Fatalf("err = %v, calls = %d, want /b to be called", err, calls)
+	}
+}
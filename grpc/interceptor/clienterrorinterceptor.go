@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/metaitself/xmeta/metaerror"
 	"google.golang.org/grpc"
+	"io"
 )
 
 func ClientErrorInterceptor() grpc.UnaryClientInterceptor {
@@ -15,3 +16,32 @@ func ClientErrorInterceptor() grpc.UnaryClientInterceptor {
 		return err
 	}
 }
+
+func StreamClientErrorInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, metaerror.FromError(err)
+		}
+		return &errorClientStream{ClientStream: cs}, nil
+	}
+}
+
+type errorClientStream struct {
+	grpc.ClientStream
+}
+
+func (s *errorClientStream) SendMsg(m interface{}) error {
+	return convertStreamError(s.ClientStream.SendMsg(m))
+}
+
+func (s *errorClientStream) RecvMsg(m interface{}) error {
+	return convertStreamError(s.ClientStream.RecvMsg(m))
+}
+
+func convertStreamError(err error) error {
+	if err == nil || err == io.EOF {
+		return err
+	}
+	return metaerror.FromError(err)
+}
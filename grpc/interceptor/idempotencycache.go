@@ -0,0 +1,131 @@
+package interceptor
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type lruEntry struct {
+	key    string
+	result *IdempotencyResult
+	expiry time.Time
+}
+
+// LRUIdempotencyCache an in-memory IdempotencyCache evicting the least recently used keys.
+type LRUIdempotencyCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+var _ IdempotencyCache = (*LRUIdempotencyCache)(nil)
+
+// NewLRUIdempotencyCache creates a cache holding at most capacity keys, besides the
+// reservations of requests in flight.
+func NewLRUIdempotencyCache(capacity int) *LRUIdempotencyCache {
+	return &LRUIdempotencyCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+func (c *LRUIdempotencyCache) Reserve(key string, ttl time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.lookup(key); ok {
+		return false
+	}
+	c.store(key, nil, ttl)
+	return true
+}
+
+func (c *LRUIdempotencyCache) Get(key string) (*IdempotencyResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.lookup(key)
+	if !ok || e.result == nil {
+		return nil, false
+	}
+	return e.result, true
+}
+
+func (c *LRUIdempotencyCache) Set(key string, result *IdempotencyResult, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.store(key, result, ttl)
+}
+
+func (c *LRUIdempotencyCache) Release(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+}
+
+// Len returns the number of keys in the cache.
+func (c *LRUIdempotencyCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ll.Len()
+}
+
+func (c *LRUIdempotencyCache) lookup(key string) (*lruEntry, bool) {
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*lruEntry)
+	if time.Now().After(e.expiry) {
+		c.remove(el)
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return e, true
+}
+
+func (c *LRUIdempotencyCache) store(key string, result *IdempotencyResult, ttl time.Duration) {
+	expiry := time.Now().Add(ttl)
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*lruEntry)
+		e.result, e.expiry = result, expiry
+		c.ll.MoveToFront(el)
+		// a completed reservation can be evicted now
+		c.evict()
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&lruEntry{key: key, result: result, expiry: expiry})
+	c.evict()
+}
+
+// evict removes the least recently used keys beyond the capacity. Reservations of
+// requests in flight are kept until they expire, evicting one would let a duplicate
+// run the handler again, so the cache may hold more keys while they complete. The
+// most recently used key is always kept.
+func (c *LRUIdempotencyCache) evict() {
+	if c.capacity <= 0 {
+		return
+	}
+	now := time.Now()
+	for el := c.ll.Back(); el != c.ll.Front() && c.ll.Len() > c.capacity; {
+		prev := el.Prev()
+		if e := el.Value.(*lruEntry); e.result != nil || now.After(e.expiry) {
+			c.remove(el)
+		}
+		el = prev
+	}
+}
+
+func (c *LRUIdempotencyCache) remove(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*lruEntry).key)
+}
@@ -0,0 +1,152 @@
+package interceptor
+
+import (
+	"context"
+	"github.com/metaitself/xmeta/metaerror"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"time"
+)
+
+// IdempotencyKeyHeader the metadata key carrying the client supplied idempotency key.
+const IdempotencyKeyHeader = "idempotency-key"
+
+// ReasonIdempotencyConflict is the reason of the error returned to a concurrent duplicate.
+const ReasonIdempotencyConflict = "IDEMPOTENCY_CONFLICT"
+
+// IdempotencyResult the stored outcome of a request.
+type IdempotencyResult struct {
+	Resp interface{}
+	Err  *metaerror.MetaError
+}
+
+// IdempotencyCache stores the results of idempotent requests.
+type IdempotencyCache interface {
+	// Reserve marks key as in flight, it returns false if the key is already reserved or stored.
+	Reserve(key string, ttl time.Duration) bool
+	// Get returns the stored result, ok is false while the key is missing or still in flight.
+	Get(key string) (result *IdempotencyResult, ok bool)
+	// Set stores the result and completes the reservation.
+	Set(key string, result *IdempotencyResult, ttl time.Duration)
+	// Release drops the reservation without storing a result.
+	Release(key string)
+}
+
+// IdempotencyOption custom setup idempotency config
+type IdempotencyOption func(*idempotencyOption)
+
+type idempotencyOption struct {
+	ttl          time.Duration
+	wait         time.Duration
+	pollInterval time.Duration
+}
+
+// WithIdempotencyTTL how long a result is replayed for.
+func WithIdempotencyTTL(ttl time.Duration) IdempotencyOption {
+	return func(opt *idempotencyOption) {
+		opt.ttl = ttl
+	}
+}
+
+// WithIdempotencyWait how long a concurrent duplicate waits for the original request,
+// 0 returns a Conflict error immediately.
+func WithIdempotencyWait(d time.Duration) IdempotencyOption {
+	return func(opt *idempotencyOption) {
+		opt.wait = d
+	}
+}
+
+// IdempotencyInterceptor replays the stored result to requests carrying an already seen
+// idempotency key. Server failures (5xx) are not stored so the client can retry them.
+func IdempotencyInterceptor(cache IdempotencyCache, opts ...IdempotencyOption) grpc.UnaryServerInterceptor {
+	opt := &idempotencyOption{
+		ttl:          24 * time.Hour,
+		pollInterval: 10 * time.Millisecond,
+	}
+	for _, f := range opts {
+		f(opt)
+	}
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		vals := md.Get(IdempotencyKeyHeader)
+		if len(vals) == 0 || vals[0] == "" {
+			return handler(ctx, req)
+		}
+		key := info.FullMethod + ":" + vals[0]
+
+		if !cache.Reserve(key, opt.ttl) {
+			result, err := waitIdempotencyResult(ctx, cache, key, opt)
+			if err != nil {
+				return nil, err
+			}
+			if result != nil {
+				if result.Err != nil {
+					return nil, result.Err
+				}
+				return result.Resp, nil
+			}
+			// the original request failed and released the key, this one reserved it
+		}
+
+		stored := false
+		defer func() {
+			if !stored {
+				cache.Release(key)
+			}
+		}()
+
+		resp, err = handler(ctx, req)
+		result := &IdempotencyResult{Resp: resp}
+		if err != nil {
+			result.Resp = nil
+			result.Err = metaerror.FromError(err)
+			if result.Err.Status >= http.StatusInternalServerError {
+				return resp, err
+			}
+		}
+		cache.Set(key, result, opt.ttl)
+		stored = true
+		return resp, err
+	}
+}
+
+// waitIdempotencyResult waits for the result of the request holding key. It returns a nil
+// result and error once it reserved the key itself, because the original request released
+// it without storing a result.
+func waitIdempotencyResult(ctx context.Context, cache IdempotencyCache, key string, opt *idempotencyOption) (*IdempotencyResult, error) {
+	if result, ok := cache.Get(key); ok {
+		return result, nil
+	}
+	if cache.Reserve(key, opt.ttl) {
+		return nil, nil
+	}
+
+	conflict := metaerror.Conflict(http.StatusConflict, ReasonIdempotencyConflict, "request with the same idempotency key is in progress")
+	if opt.wait <= 0 {
+		return nil, conflict
+	}
+
+	timer := time.NewTimer(opt.wait)
+	defer timer.Stop()
+	ticker := time.NewTicker(opt.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		case <-timer.C:
+			return nil, conflict
+		case <-ticker.C:
+			if result, ok := cache.Get(key); ok {
+				return result, nil
+			}
+			if cache.Reserve(key, opt.ttl) {
+				return nil, nil
+			}
+		}
+	}
+}
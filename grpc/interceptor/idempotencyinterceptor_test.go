@@ -0,0 +1,229 @@
+package interceptor
+
+import (
+	"context"
+	"github.com/metaitself/xmeta/metaerror"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLRUIdempotencyCache(t *testing.T) {
+	c := NewLRUIdempotencyCache(10)
+	if !c.Reserve("k", time.Minute) {
+		t.Fatal("reserve a new key")
+	}
+	if c.Reserve("k", time.Minute) {
+		t.Fatal("reserved a key in flight twice")
+	}
+	if _, ok := c.Get("k"); ok {
+		t.Fatal("got a result for a key in flight")
+	}
+
+	c.Set("k", &IdempotencyResult{Resp: "r"}, time.Minute)
+	if result, ok := c.Get("k"); !ok || result.Resp != "r" {
+		t.Fatalf("Get = %v, %v, want the stored result", result, ok)
+	}
+	if c.Reserve("k", time.Minute) {
+		t.Fatal("reserved a stored key")
+	}
+
+	c.Reserve("released", time.Minute)
+	c.Release("released")
+	if !c.Reserve("released", time.Minute) {
+		t.Fatal("a released key can be reserved again")
+	}
+
+	c.Set("expired", &IdempotencyResult{Resp: "r"}, -time.Second)
+	if _, ok := c.Get("expired"); ok {
+		t.Fatal("got an expired result")
+	}
+	if !c.Reserve("expired", time.Minute) {
+		t.Fatal("an expired key can be reserved again")
+	}
+}
+
+func TestLRUIdempotencyCacheKeepsReservations(t *testing.T) {
+	c := NewLRUIdempotencyCache(2)
+	c.Reserve("a", time.Minute)
+	c.Reserve("b", time.Minute)
+	c.Set("c", &IdempotencyResult{Resp: "c"}, time.Minute)
+	c.Set("d", &IdempotencyResult{Resp: "d"}, time.Minute)
+
+	if c.Reserve("a", time.Minute) || c.Reserve("b", time.Minute) {
+		t.Fatal("evicted a reservation in flight")
+	}
+	if _, ok := c.Get("c"); ok {
+		t.Fatal("the least recently used result wasn't evicted")
+	}
+	if _, ok := c.Get("d"); !ok {
+		t.Fatal("evicted the latest result")
+	}
+
+	// completing the reservations shrinks the cache back to its capacity
+	c.Set("a", &IdempotencyResult{Resp: "a"}, time.Minute)
+	c.Set("b", &IdempotencyResult{Resp: "b"}, time.Minute)
+	if n := c.Len(); n != 2 {
+		t.Fatalf("Len = %d, want 2", n)
+	}
+
+	// expired reservations are evicted
+	c = NewLRUIdempotencyCache(1)
+	c.Reserve("stale", -time.Second)
+	c.Reserve("fresh", time.Minute)
+	if n := c.Len(); n != 1 || c.Reserve("fresh", time.Minute) {
+		t.Fatalf("Len = %d, want only the fresh reservation", n)
+	}
+}
+
+var testIdempotencyInfo = &grpc.UnaryServerInfo{FullMethod: "/test.Pay/Charge"}
+
+func idempotentContext(key string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(IdempotencyKeyHeader, key))
+}
+
+func TestIdempotencyInterceptorReplay(t *testing.T) {
+	interceptor := IdempotencyInterceptor(NewLRUIdempotencyCache(10))
+	var calls atomic.Int32
+	handler := func(_ context.Context, req interface{}) (interface{}, error) {
+		calls.Add(1)
+		if req == "bad" {
+			return nil, metaerror.BadRequest(http.StatusBadRequest, "BAD", "bad request")
+		}
+		return req, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		resp, err := interceptor(idempotentContext("k1"), "ok", testIdempotencyInfo, handler)
+		if err != nil || resp != "ok" {
+			t.Fatalf("call %d = %v, %v", i, resp, err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("handler called %d times, want 1", n)
+	}
+
+	// client errors are stored and replayed too
+	for i := 0; i < 2; i++ {
+		_, err := interceptor(idempotentContext("k2"), "bad", testIdempotencyInfo, handler)
+		if me := metaerror.FromError(err); me.Reason != "BAD" {
+			t.Fatalf("call %d error = %v, want the bad request", i, err)
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("handler called %d times, want 2", n)
+	}
+
+	// requests without a key always run
+	for i := 0; i < 2; i++ {
+		_, _ = interceptor(context.Background(), "ok", testIdempotencyInfo, handler)
+	}
+	if n := calls.Load(); n != 4 {
+		t.Fatalf("handler called %d times, want 4", n)
+	}
+}
+
+func TestIdempotencyInterceptorRetriesServerFailures(t *testing.T) {
+	interceptor := IdempotencyInterceptor(NewLRUIdempotencyCache(10))
+	var calls atomic.Int32
+	handler := func(context.Context, interface{}) (interface{}, error) {
+		if calls.Add(1) == 1 {
+			return nil, metaerror.InternalServer(http.StatusInternalServerError, "DOWN", "down")
+		}
+		return "ok", nil
+	}
+
+	if _, err := interceptor(idempotentContext("k"), nil, testIdempotencyInfo, handler); err == nil {
+		t.Fatal("expected the server failure")
+	}
+	resp, err := interceptor(idempotentContext("k"), nil, testIdempotencyInfo, handler)
+	if err != nil || resp != "ok" || calls.Load() != 2 {
+		t.Fatalf("retry = %v, %v after %d calls, want the handler to run again", resp, err, calls.Load())
+	}
+}
+
+// blockingHandler holds the first request until release is closed.
+func blockingHandler(started chan<- struct{}, release <-chan struct{}, fail bool) grpc.UnaryHandler {
+	var calls atomic.Int32
+	return func(context.Context, interface{}) (interface{}, error) {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-release
+			if fail {
+				return nil, metaerror.InternalServer(http.StatusInternalServerError, "DOWN", "down")
+			}
+			return "first", nil
+		}
+		return "second", nil
+	}
+}
+
+func TestIdempotencyInterceptorConcurrentDuplicate(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []IdempotencyOption
+		fail bool
+		// want the response of the duplicate, a conflict error when empty
+		want string
+	}{
+		{name: "conflict"},
+		{name: "wait for the result", opts: []IdempotencyOption{WithIdempotencyWait(5 * time.Second)}, want: "first"},
+		{name: "take over after release", opts: []IdempotencyOption{WithIdempotencyWait(5 * time.Second)}, fail: true, want: "second"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interceptor := IdempotencyInterceptor(NewLRUIdempotencyCache(10), tt.opts...)
+			started, release := make(chan struct{}), make(chan struct{})
+			handler := blockingHandler(started, release, tt.fail)
+
+			first := make(chan error, 1)
+			go func() {
+				_, err := interceptor(idempotentContext("k"), nil, testIdempotencyInfo, handler)
+				first <- err
+			}()
+			<-started
+
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				close(release)
+			}()
+			resp, err := interceptor(idempotentContext("k"), nil, testIdempotencyInfo, handler)
+			if tt.want != "" {
+				if err != nil || resp != tt.want {
+					t.Fatalf("duplicate = %v, %v, want %q", resp, err, tt.want)
+				}
+			} else if me := metaerror.FromError(err); me.Reason != ReasonIdempotencyConflict {
+				t.Fatalf("duplicate error = %v, want a conflict", err)
+			}
+			<-first
+		})
+	}
+}
+
+func TestIdempotencyInterceptorContextError(t *testing.T) {
+	interceptor := IdempotencyInterceptor(NewLRUIdempotencyCache(10), WithIdempotencyWait(5*time.Second))
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	handler := blockingHandler(started, release, false)
+	go func() { _, _ = interceptor(idempotentContext("k"), nil, testIdempotencyInfo, handler) }()
+	<-started
+
+	canceled, cancel := context.WithCancel(idempotentContext("k"))
+	cancel()
+	_, err := interceptor(canceled, nil, testIdempotencyInfo, handler)
+	if status.Code(err) != codes.Canceled {
+		t.Fatalf("canceled error = %v, want Canceled", err)
+	}
+
+	expired, cancel := context.WithTimeout(idempotentContext("k"), 20*time.Millisecond)
+	defer cancel()
+	_, err = interceptor(expired, nil, testIdempotencyInfo, handler)
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("deadline error = %v, want DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,68 @@
+package interceptor
+
+import (
+	"context"
+	"github.com/metaitself/xmeta/logger"
+	"github.com/metaitself/xmeta/metaerror"
+	"google.golang.org/grpc"
+	"time"
+)
+
+func ServerLoggingInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logCall("grpc server", info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+func StreamServerLoggingInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logCall("grpc server stream", info.FullMethod, start, err)
+		return err
+	}
+}
+
+func ClientLoggingInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		logCall("grpc client", method, start, err)
+		return err
+	}
+}
+
+func StreamClientLoggingInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			logCall("grpc client stream", method, start, err)
+			return nil, err
+		}
+		return newFinishClientStream(cs, desc, func(err error) {
+			logCall("grpc client stream", method, start, err)
+		}), nil
+	}
+}
+
+func logCall(msg, method string, start time.Time, err error) {
+	fields := []logger.Field{
+		logger.String("method", method),
+		logger.Duration("elapsed", time.Since(start)),
+	}
+	if err == nil {
+		logger.Info(msg, fields...)
+		return
+	}
+
+	fields = append(fields, logger.MetaErr(err))
+	if metaerror.StatusCode(err) >= 500 {
+		logger.Error(msg, fields...)
+	} else {
+		logger.Warn(msg, fields...)
+	}
+}
@@ -0,0 +1,49 @@
+package interceptor
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+func ServerMetricsInterceptor(recorder MetricsRecorder) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		recorder.Observe(info.FullMethod, status.Code(err), time.Since(start))
+		return resp, err
+	}
+}
+
+func StreamServerMetricsInterceptor(recorder MetricsRecorder) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		recorder.Observe(info.FullMethod, status.Code(err), time.Since(start))
+		return err
+	}
+}
+
+func ClientMetricsInterceptor(recorder MetricsRecorder) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		recorder.Observe(method, status.Code(err), time.Since(start))
+		return err
+	}
+}
+
+func StreamClientMetricsInterceptor(recorder MetricsRecorder) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			recorder.Observe(method, status.Code(err), time.Since(start))
+			return nil, err
+		}
+		return newFinishClientStream(cs, desc, func(err error) {
+			recorder.Observe(method, status.Code(err), time.Since(start))
+		}), nil
+	}
+}
@@ -0,0 +1,67 @@
+package interceptor
+
+import (
+	"context"
+	"fmt"
+	"github.com/metaitself/xmeta/logger"
+	"github.com/metaitself/xmeta/metaerror"
+	"google.golang.org/grpc"
+	"runtime"
+)
+
+func ServerRecoveryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recoverError(info.FullMethod, r)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+func StreamServerRecoveryInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recoverError(info.FullMethod, r)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+func ClientRecoveryInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recoverError(method, r)
+			}
+		}()
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func StreamClientRecoveryInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (cs grpc.ClientStream, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recoverError(method, r)
+			}
+		}()
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+// recoverError logs the panic with its stack and converts it to an InternalServer error.
+func recoverError(method string, r any) error {
+	const size = 64 << 10
+	buf := make([]byte, size)
+	buf = buf[:runtime.Stack(buf, false)]
+	logger.Error("grpc panic recovered",
+		logger.String("method", method),
+		logger.Any("panic", r),
+		logger.ByteString("stack", buf),
+	)
+	return metaerror.InternalServer(metaerror.UnknownCode, "PANIC", fmt.Sprintf("%v", r))
+}
@@ -15,3 +15,13 @@ func ServerErrorInterceptor() grpc.UnaryServerInterceptor {
 		return resp, err
 	}
 }
+
+func StreamServerErrorInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		err := handler(srv, ss)
+		if err != nil {
+			err = metaerror.FromError(err).GRPCStatus().Err()
+		}
+		return err
+	}
+}
@@ -0,0 +1,42 @@
+package interceptor
+
+import (
+	"context"
+	"google.golang.org/grpc"
+)
+
+func ServerTracingInterceptor(tracer Tracer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		ctx, end := tracer.Start(ctx, info.FullMethod)
+		defer func() { end(err) }()
+		return handler(ctx, req)
+	}
+}
+
+func StreamServerTracingInterceptor(tracer Tracer) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		ctx, end := tracer.Start(ss.Context(), info.FullMethod)
+		defer func() { end(err) }()
+		return handler(srv, &wrappedServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func ClientTracingInterceptor(tracer Tracer) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) (err error) {
+		ctx, end := tracer.Start(ctx, method)
+		defer func() { end(err) }()
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func StreamClientTracingInterceptor(tracer Tracer) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, end := tracer.Start(ctx, method)
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			end(err)
+			return nil, err
+		}
+		return newFinishClientStream(cs, desc, end), nil
+	}
+}
@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"errors"
+	"go.uber.org/zap/zapcore"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrAsyncClosed is returned when writing to a closed AsyncWriteSyncer.
+var ErrAsyncClosed = errors.New("logger: async write syncer closed")
+
+// DefaultAsyncQueueSize the default number of entries buffered by an AsyncWriteSyncer.
+const DefaultAsyncQueueSize = 8192
+
+type asyncEntry struct {
+	b     []byte
+	flush chan struct{}
+}
+
+// AsyncWriteSyncer writes to the underlying WriteSyncer from a background goroutine
+// through a bounded queue, so slow outputs don't stall the caller.
+type AsyncWriteSyncer struct {
+	ws    zapcore.WriteSyncer
+	queue chan asyncEntry
+	block bool
+
+	dropped atomic.Uint64
+
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+// NewAsyncWriteSyncer creates an AsyncWriteSyncer with a queue of size entries,
+// when the queue is full Write blocks if block is true, otherwise the entry is dropped.
+func NewAsyncWriteSyncer(ws zapcore.WriteSyncer, size int, block bool) *AsyncWriteSyncer {
+	if size <= 0 {
+		size = DefaultAsyncQueueSize
+	}
+	a := &AsyncWriteSyncer{
+		ws:    ws,
+		queue: make(chan asyncEntry, size),
+		block: block,
+		done:  make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+func (a *AsyncWriteSyncer) run() {
+	defer close(a.done)
+	for e := range a.queue {
+		if e.flush != nil {
+			close(e.flush)
+			continue
+		}
+		_, _ = a.ws.Write(e.b)
+	}
+}
+
+// Write enqueues a copy of p, the encoder reuses its buffer after Write returns.
+func (a *AsyncWriteSyncer) Write(p []byte) (int, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		return 0, ErrAsyncClosed
+	}
+
+	b := make([]byte, len(p))
+	copy(b, p)
+	if a.block {
+		a.queue <- asyncEntry{b: b}
+		return len(p), nil
+	}
+
+	select {
+	case a.queue <- asyncEntry{b: b}:
+	default:
+		a.dropped.Add(1)
+	}
+	return len(p), nil
+}
+
+// Sync waits until all queued entries are written and syncs the underlying WriteSyncer.
+func (a *AsyncWriteSyncer) Sync() error {
+	a.mu.RLock()
+	if a.closed {
+		a.mu.RUnlock()
+		return nil
+	}
+	flush := make(chan struct{})
+	a.queue <- asyncEntry{flush: flush}
+	a.mu.RUnlock()
+
+	<-flush
+	return a.ws.Sync()
+}
+
+// Close flushes the queue and stops the background goroutine.
+func (a *AsyncWriteSyncer) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	close(a.queue)
+	a.mu.Unlock()
+
+	<-a.done
+	return a.ws.Sync()
+}
+
+// Dropped returns the number of entries dropped because the queue was full.
+func (a *AsyncWriteSyncer) Dropped() uint64 {
+	return a.dropped.Load()
+}
@@ -0,0 +1,355 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"github.com/metaitself/xmeta/encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix the prefix of the environment variables overriding a Config.
+const EnvPrefix = "XMETA_LOG_"
+
+// ConfigDuration is a time.Duration read from "1.5s" strings in JSON and YAML configs,
+// JSON numbers are nanoseconds.
+type ConfigDuration time.Duration
+
+func (d ConfigDuration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *ConfigDuration) UnmarshalText(b []byte) error {
+	v, err := time.ParseDuration(string(b))
+	if err != nil {
+		return fmt.Errorf("logger: invalid duration %q: %w", b, err)
+	}
+	*d = ConfigDuration(v)
+	return nil
+}
+
+func (d *ConfigDuration) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		return d.UnmarshalText([]byte(s))
+	}
+	var n int64
+	if err := json.Unmarshal(b, &n); err != nil {
+		return fmt.Errorf("logger: invalid duration %s: %w", b, err)
+	}
+	*d = ConfigDuration(n)
+	return nil
+}
+
+// OutputConfig one log output.
+type OutputConfig struct {
+	// Type is one of "console", "file", "syslog" or "tcp"
+	Type string `json:"type" yaml:"type"`
+	// Level only entries at or above level are written to a file output, e.g. an error.log
+	Level string `json:"level" yaml:"level"`
+	// Rotation the file of a file output
+	Rotation RotationConfig `json:"rotation" yaml:"rotation"`
+	// Network and Address of a syslog ("unixgram", "unix", "udp") or tcp output
+	Network string `json:"network" yaml:"network"`
+	Address string `json:"address" yaml:"address"`
+	// Facility and AppName of a syslog output, the facility defaults to user (1)
+	Facility int    `json:"facility" yaml:"facility"`
+	AppName  string `json:"app_name" yaml:"app_name"`
+}
+
+// AsyncConfig see WithAsync.
+type AsyncConfig struct {
+	Size  int  `json:"size" yaml:"size"`
+	Block bool `json:"block" yaml:"block"`
+}
+
+// Config the declarative form of the logger options, see FromConfig.
+type Config struct {
+	Level string `json:"level" yaml:"level"`
+	// Modules the levels of named sub-loggers, keys may be glob patterns such as "rpc.*"
+	Modules      map[string]string `json:"modules" yaml:"modules"`
+	Encoding     string            `json:"encoding" yaml:"encoding"`
+	TimeLayout   string            `json:"time_layout" yaml:"time_layout"`
+	Caller       bool              `json:"caller" yaml:"caller"`
+	CallerSkip   int               `json:"caller_skip" yaml:"caller_skip"`
+	MetadataKeys []string          `json:"metadata_keys" yaml:"metadata_keys"`
+	// Outputs defaults to the console
+	Outputs   []OutputConfig   `json:"outputs" yaml:"outputs"`
+	Async     *AsyncConfig     `json:"async" yaml:"async"`
+	Sampling  *SamplingConfig  `json:"sampling" yaml:"sampling"`
+	Redaction *RedactionConfig `json:"redaction" yaml:"redaction"`
+	// ReopenOnSIGHUP reopens the file outputs on SIGHUP
+	ReopenOnSIGHUP bool `json:"reopen_on_sighup" yaml:"reopen_on_sighup"`
+}
+
+// LoadConfigFile reads a JSON config file. YAML files should be decoded with a YAML
+// library into Config, the fields carry yaml tags.
+func LoadConfigFile(path string) (Config, error) {
+	var cfg Config
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+	case ".yaml", ".yml":
+		return cfg, fmt.Errorf("logger: decode %s with a YAML library and call FromConfig", path)
+	default:
+		return cfg, fmt.Errorf("logger: unsupported config file %s", path)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err = json.Unmarshal(b, &cfg); err != nil {
+		return cfg, fmt.Errorf("logger: parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// ApplyEnv overrides cfg with the XMETA_LOG_* environment variables:
+// LEVEL, MODULES ("rpc.*=debug,payment=warn"), ENCODING, TIME_LAYOUT, CALLER and FILE.
+// FILE replaces the file of the output without a level. The Modules map and the Outputs
+// slice are copied before they are changed, a shared Config isn't modified.
+func (c *Config) ApplyEnv() error {
+	if v, ok := lookupEnv("LEVEL"); ok {
+		c.Level = v
+	}
+	if v, ok := lookupEnv("MODULES"); ok {
+		modules := make(map[string]string, len(c.Modules))
+		maps.Copy(modules, c.Modules)
+		c.Modules = modules
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			pattern, level, ok := strings.Cut(item, "=")
+			if !ok {
+				return fmt.Errorf("logger: invalid %sMODULES entry %q", EnvPrefix, item)
+			}
+			c.Modules[strings.TrimSpace(pattern)] = strings.TrimSpace(level)
+		}
+	}
+	if v, ok := lookupEnv("ENCODING"); ok {
+		c.Encoding = v
+	}
+	if v, ok := lookupEnv("TIME_LAYOUT"); ok {
+		c.TimeLayout = v
+	}
+	if v, ok := lookupEnv("CALLER"); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("logger: invalid %sCALLER %q", EnvPrefix, v)
+		}
+		c.Caller = b
+	}
+	if v, ok := lookupEnv("FILE"); ok {
+		c.setFile(v)
+	}
+	return nil
+}
+
+// setFile replaces the filename of the file output without a level, or adds one.
+func (c *Config) setFile(filename string) {
+	c.Outputs = slices.Clone(c.Outputs)
+	for i, o := range c.Outputs {
+		if o.Type == "file" && o.Level == "" {
+			c.Outputs[i].Rotation.Filename = filename
+			return
+		}
+	}
+	c.Outputs = append(c.Outputs, OutputConfig{Type: "file", Rotation: DefaultRotationConfig(filename)})
+}
+
+func lookupEnv(name string) (string, bool) {
+	v, ok := os.LookupEnv(EnvPrefix + name)
+	if !ok || v == "" {
+		return "", false
+	}
+	return v, true
+}
+
+// Validate reports every invalid value, unknown levels aren't silently turned into info.
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Level != "" {
+		if _, err := ParseLevel(c.Level); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for pattern, level := range c.Modules {
+		if _, err := ParseLevel(level); err != nil {
+			errs = append(errs, fmt.Errorf("module %q: %w", pattern, err))
+		}
+	}
+	switch c.Encoding {
+	case "", "json", "console":
+	default:
+		errs = append(errs, fmt.Errorf("logger: unknown encoding %q", c.Encoding))
+	}
+	if c.CallerSkip < 0 {
+		errs = append(errs, fmt.Errorf("logger: negative caller skip %d", c.CallerSkip))
+	}
+	files := 0
+	for i, o := range c.Outputs {
+		if err := o.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("output %d: %w", i, err))
+		}
+		if o.Type == "file" && o.Level == "" {
+			files++
+		}
+	}
+	if files > 1 {
+		errs = append(errs, errors.New("logger: only one file output without a level is supported"))
+	}
+	if c.Async != nil && c.Async.Size < 0 {
+		errs = append(errs, fmt.Errorf("logger: negative async size %d", c.Async.Size))
+	}
+	if c.Sampling != nil {
+		if err := c.Sampling.withDefaults().validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if c.Redaction != nil {
+		if _, err := newRedactor(*c.Redaction); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (o OutputConfig) validate() error {
+	if o.Level != "" {
+		if _, err := ParseLevel(o.Level); err != nil {
+			return err
+		}
+	}
+	switch o.Type {
+	case "console":
+	case "file":
+		if o.Rotation.Filename == "" {
+			return errors.New("logger: file output requires rotation.filename")
+		}
+		switch o.Rotation.Interval {
+		case RotateNone, RotateHourly, RotateDaily:
+		default:
+			return fmt.Errorf("logger: unknown rotation interval %q", o.Rotation.Interval)
+		}
+	case "syslog", "tcp":
+		if o.Address == "" {
+			return fmt.Errorf("logger: %s output requires address", o.Type)
+		}
+		if o.Type == "syslog" && (o.Facility < 0 || o.Facility > 23) {
+			return fmt.Errorf("logger: syslog facility %d out of range 0-23", o.Facility)
+		}
+	default:
+		return fmt.Errorf("logger: unknown output type %q", o.Type)
+	}
+	return nil
+}
+
+// Options converts the config to logger options. The syslog and tcp sinks are only
+// created by NewLogger, which closes them when a later option fails.
+func (c *Config) Options() ([]Option, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	var opts []Option
+	if c.Level != "" {
+		lvl, _ := ParseLevel(c.Level)
+		opts = append(opts, WithLevel(lvl.String()))
+	}
+	if c.Encoding != "" {
+		opts = append(opts, WithEncode(c.Encoding))
+	}
+	if c.TimeLayout != "" {
+		opts = append(opts, WithTimeLayout(c.TimeLayout))
+	}
+	if c.Caller {
+		opts = append(opts, WithCaller(true))
+		if c.CallerSkip > 0 {
+			opts = append(opts, AddCallerSkip(c.CallerSkip))
+		}
+	}
+	if len(c.MetadataKeys) > 0 {
+		opts = append(opts, WithMetadataKeys(c.MetadataKeys...))
+	}
+
+	console := len(c.Outputs) == 0
+	for _, o := range c.Outputs {
+		switch o.Type {
+		case "console":
+			console = true
+		case "file":
+			if o.Level != "" {
+				opts = append(opts, WithLevelRotation(o.Level, o.Rotation))
+			} else {
+				opts = append(opts, WithRotation(o.Rotation))
+			}
+		case "syslog":
+			network := o.Network
+			if network == "" {
+				network = "udp"
+			}
+			facility := SyslogFacility(o.Facility)
+			if facility == 0 {
+				facility = FacilityUser
+			}
+			address, appName := o.Address, o.AppName
+			opts = append(opts, withSinkFunc(func() (Sink, error) {
+				return NewSyslogSink(network, address, facility, appName)
+			}))
+		case "tcp":
+			address := o.Address
+			opts = append(opts, withSinkFunc(func() (Sink, error) {
+				return NewTCPSink(address), nil
+			}))
+		}
+	}
+	if !console {
+		opts = append(opts, WithDisableConsole())
+	}
+
+	if c.Async != nil {
+		opts = append(opts, WithAsync(c.Async.Size, c.Async.Block))
+	}
+	if c.Sampling != nil {
+		opts = append(opts, WithSampling(*c.Sampling))
+	}
+	if c.Redaction != nil {
+		opts = append(opts, WithRedaction(*c.Redaction))
+	}
+	if c.ReopenOnSIGHUP {
+		opts = append(opts, WithReopenOnSIGHUP())
+	}
+	return opts, nil
+}
+
+// FromConfig applies the environment overrides to cfg, validates it and builds a logger.
+func FromConfig(cfg Config) (Logger, error) {
+	if err := cfg.ApplyEnv(); err != nil {
+		return nil, err
+	}
+	opts, err := cfg.Options()
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := NewLogger(opts...)
+	if err != nil {
+		return nil, err
+	}
+	for pattern, level := range cfg.Modules {
+		if err = setModuleLevel(l, pattern, level); err != nil {
+			_ = l.(*zapLogger).Close()
+			return nil, err
+		}
+	}
+	return l, nil
+}
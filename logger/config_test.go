@@ -0,0 +1,252 @@
+package logger
+
+import (
+	"github.com/metaitself/xmeta/encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigDurationJSON(t *testing.T) {
+	var cfg Config
+	err := json.Unmarshal([]byte(`{"sampling":{"tick":"1s","first":10,"report_interval":60000000000}}`), &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := time.Duration(cfg.Sampling.Tick); got != time.Second {
+		t.Fatalf("tick = %v, want 1s", got)
+	}
+	if got := time.Duration(cfg.Sampling.ReportInterval); got != time.Minute {
+		t.Fatalf("report interval = %v, want 1m", got)
+	}
+	if err = json.Unmarshal([]byte(`{"sampling":{"tick":"soon"}}`), &cfg); err == nil {
+		t.Fatal("expected an error for an invalid duration")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		err  string
+	}{
+		{name: "empty", cfg: Config{}},
+		{name: "unknown level", cfg: Config{Level: "loud"}, err: "loud"},
+		{name: "unknown module level", cfg: Config{Modules: map[string]string{"rpc.*": "loud"}}, err: `module "rpc.*"`},
+		{name: "unknown encoding", cfg: Config{Encoding: "xml"}, err: "unknown encoding"},
+		{
+			name: "two file outputs",
+			cfg: Config{Outputs: []OutputConfig{
+				{Type: "file", Rotation: RotationConfig{Filename: "a.log"}},
+				{Type: "file", Rotation: RotationConfig{Filename: "b.log"}},
+			}},
+			err: "only one file output",
+		},
+		{
+			name: "leveled file output",
+			cfg: Config{Outputs: []OutputConfig{
+				{Type: "file", Rotation: RotationConfig{Filename: "a.log"}},
+				{Type: "file", Level: "error", Rotation: RotationConfig{Filename: "error.log"}},
+			}},
+		},
+		{name: "syslog facility", cfg: Config{Outputs: []OutputConfig{{Type: "syslog", Address: "localhost:514", Facility: 23}}}},
+		{name: "syslog facility too large", cfg: Config{Outputs: []OutputConfig{{Type: "syslog", Address: "localhost:514", Facility: 24}}}, err: "out of range"},
+		{name: "negative syslog facility", cfg: Config{Outputs: []OutputConfig{{Type: "syslog", Address: "localhost:514", Facility: -1}}}, err: "out of range"},
+		{name: "tcp without address", cfg: Config{Outputs: []OutputConfig{{Type: "tcp"}}}, err: "requires address"},
+		{name: "default sampling", cfg: Config{Sampling: &SamplingConfig{}}},
+		{name: "negative sampling", cfg: Config{Sampling: &SamplingConfig{SamplingRule: SamplingRule{First: -1}}}, err: "negative"},
+		{
+			name: "drop all override",
+			cfg:  Config{Sampling: &SamplingConfig{Overrides: map[string]SamplingRule{"boom": {}}}},
+			err:  `sampling override "boom"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("error = %v, want it to contain %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestConfigApplyEnv(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   map[string]string
+		cfg   Config
+		check func(t *testing.T, cfg Config)
+		err   string
+	}{
+		{
+			name: "level",
+			env:  map[string]string{"LEVEL": "warn"},
+			check: func(t *testing.T, cfg Config) {
+				if cfg.Level != "warn" {
+					t.Fatalf("level = %q", cfg.Level)
+				}
+			},
+		},
+		{
+			name: "modules",
+			env:  map[string]string{"MODULES": " rpc.* = debug , payment=warn,"},
+			cfg:  Config{Modules: map[string]string{"db": "error"}},
+			check: func(t *testing.T, cfg Config) {
+				want := map[string]string{"db": "error", "rpc.*": "debug", "payment": "warn"}
+				if len(cfg.Modules) != len(want) {
+					t.Fatalf("modules = %v", cfg.Modules)
+				}
+				for k, v := range want {
+					if cfg.Modules[k] != v {
+						t.Fatalf("modules = %v", cfg.Modules)
+					}
+				}
+			},
+		},
+		{name: "invalid modules", env: map[string]string{"MODULES": "rpc"}, err: "MODULES"},
+		{name: "invalid caller", env: map[string]string{"CALLER": "maybe"}, err: "CALLER"},
+		{
+			name: "file replaces the file output",
+			env:  map[string]string{"FILE": "env.log"},
+			cfg: Config{Outputs: []OutputConfig{
+				{Type: "console"},
+				{Type: "file", Level: "error", Rotation: RotationConfig{Filename: "error.log"}},
+				{Type: "file", Rotation: RotationConfig{Filename: "app.log", MaxSize: 10}},
+			}},
+			check: func(t *testing.T, cfg Config) {
+				if len(cfg.Outputs) != 3 {
+					t.Fatalf("outputs = %+v", cfg.Outputs)
+				}
+				if f := cfg.Outputs[1].Rotation.Filename; f != "error.log" {
+					t.Fatalf("leveled file = %q", f)
+				}
+				if r := cfg.Outputs[2].Rotation; r.Filename != "env.log" || r.MaxSize != 10 {
+					t.Fatalf("file output = %+v", r)
+				}
+				if err := cfg.Validate(); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "file adds a file output",
+			env:  map[string]string{"FILE": "env.log"},
+			check: func(t *testing.T, cfg Config) {
+				if len(cfg.Outputs) != 1 || cfg.Outputs[0].Type != "file" || cfg.Outputs[0].Rotation.Filename != "env.log" {
+					t.Fatalf("outputs = %+v", cfg.Outputs)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(EnvPrefix+k, v)
+			}
+			err := tt.cfg.ApplyEnv()
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, tt.cfg)
+		})
+	}
+}
+
+func TestConfigApplyEnvKeepsSharedConfig(t *testing.T) {
+	t.Setenv(EnvPrefix+"FILE", "env.log")
+	t.Setenv(EnvPrefix+"MODULES", "rpc=debug")
+	shared := Config{
+		Modules: map[string]string{"db": "error"},
+		Outputs: []OutputConfig{{Type: "file", Rotation: RotationConfig{Filename: "app.log"}}},
+	}
+	cfg := shared
+	if err := cfg.ApplyEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if shared.Outputs[0].Rotation.Filename != "app.log" {
+		t.Fatalf("shared outputs changed: %+v", shared.Outputs)
+	}
+	if _, ok := shared.Modules["rpc"]; ok {
+		t.Fatalf("shared modules changed: %v", shared.Modules)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name string
+		env  map[string]string
+		cfg  Config
+		err  string
+	}{
+		{
+			name: "env file with a file output",
+			env:  map[string]string{"FILE": filepath.Join(dir, "env.log")},
+			cfg: Config{Outputs: []OutputConfig{
+				{Type: "file", Rotation: RotationConfig{Filename: filepath.Join(dir, "app.log")}},
+			}},
+		},
+		{
+			name: "modules",
+			cfg:  Config{Level: "info", Modules: map[string]string{"rpc.*": "debug"}},
+		},
+		{
+			name: "invalid facility",
+			cfg:  Config{Outputs: []OutputConfig{{Type: "syslog", Address: "127.0.0.1:1", Facility: 99}}},
+			err:  "out of range",
+		},
+		{
+			name: "drop all sampling",
+			cfg:  Config{Sampling: &SamplingConfig{Overrides: map[string]SamplingRule{"boom": {}}}},
+			err:  "drops every entry",
+		},
+		{
+			name: "env level",
+			env:  map[string]string{"LEVEL": "loud"},
+			err:  "loud",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(EnvPrefix+k, v)
+			}
+			l, err := FromConfig(tt.cfg)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = l.(*zapLogger).Close()
+		})
+	}
+}
+
+func TestConfigOptionsDoesNotDial(t *testing.T) {
+	cfg := Config{Outputs: []OutputConfig{{Type: "syslog", Network: "unix", Address: filepath.Join(t.TempDir(), "missing.sock")}}}
+	opts, err := cfg.Options()
+	if err != nil {
+		t.Fatalf("options dialed the syslog sink: %v", err)
+	}
+	if _, err = NewLogger(opts...); err == nil {
+		t.Fatal("expected NewLogger to fail dialing the syslog sink")
+	}
+}
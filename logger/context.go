@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"context"
+	"github.com/metaitself/xmeta/metadata"
+)
+
+type fieldsContextKey struct{}
+
+// NewContext returns a context carrying fields, which are added to every entry
+// logged through Ctx or the *Ctx functions.
+func NewContext(ctx context.Context, fields ...Field) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	prev, _ := ctx.Value(fieldsContextKey{}).([]Field)
+	fs := make([]Field, 0, len(prev)+len(fields))
+	fs = append(fs, prev...)
+	fs = append(fs, fields...)
+	return context.WithValue(ctx, fieldsContextKey{}, fs)
+}
+
+// FieldsFromContext returns the fields stored in ctx and the metadata keys
+// configured for the default logger.
+func FieldsFromContext(ctx context.Context) []Field {
+	var keys []string
+	if zl, ok := Default().(*zapLogger); ok {
+		keys = zl.metadataKeys
+	}
+	return fieldsFromContext(ctx, keys)
+}
+
+func fieldsFromContext(ctx context.Context, keys []string) []Field {
+	if ctx == nil {
+		return nil
+	}
+
+	fs, _ := ctx.Value(fieldsContextKey{}).([]Field)
+	if len(keys) == 0 {
+		return fs
+	}
+
+	md, ok := metadata.ViewFromContext(ctx)
+	if !ok {
+		return fs
+	}
+	out := make([]Field, 0, len(fs)+len(keys))
+	out = append(out, fs...)
+	for _, k := range keys {
+		if v := md.Get(k); v != nil {
+			out = append(out, Any(k, v))
+		}
+	}
+	return out
+}
+
+// Ctx returns a child of the default logger with the fields carried by ctx.
+func Ctx(ctx context.Context) Logger {
+	fs := FieldsFromContext(ctx)
+	return newLazyLogger(nil, func(l Logger) Logger { return l.With(fs...) })
+}
+
+func DebugCtx(ctx context.Context, msg string, fields ...Field) {
+	std().Debug(msg, append(FieldsFromContext(ctx), fields...)...)
+}
+
+func InfoCtx(ctx context.Context, msg string, fields ...Field) {
+	std().Info(msg, append(FieldsFromContext(ctx), fields...)...)
+}
+
+func WarnCtx(ctx context.Context, msg string, fields ...Field) {
+	std().Warn(msg, append(FieldsFromContext(ctx), fields...)...)
+}
+
+func ErrorCtx(ctx context.Context, msg string, fields ...Field) {
+	std().Error(msg, append(FieldsFromContext(ctx), fields...)...)
+}
+
+func FatalCtx(ctx context.Context, msg string, fields ...Field) {
+	std().Fatal(msg, append(FieldsFromContext(ctx), fields...)...)
+}
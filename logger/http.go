@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"github.com/metaitself/xmeta/encoding/json"
+	"net/http"
+)
+
+type levelRequest struct {
+	// Module the module name or glob pattern, empty for the root level
+	Module string `json:"module"`
+	// Level the new level, empty removes the module rule
+	Level string `json:"level"`
+}
+
+// LevelHandler lists the levels of the default logger on GET, and changes the
+// level of the root or of a module on PUT with a body like
+// {"module": "rpc.*", "level": "debug"}.
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var req levelRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeLevelError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			if req.Module == "" && req.Level == "" {
+				writeLevelError(w, http.StatusBadRequest, "level is required")
+				return
+			}
+			if err := SetModuleLevel(req.Module, req.Level); err != nil {
+				writeLevelError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			writeLevelError(w, http.StatusMethodNotAllowed, "only GET and PUT are supported")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(GetLevels())
+	})
+}
+
+func writeLevelError(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
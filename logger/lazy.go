@@ -0,0 +1,111 @@
+package logger
+
+import (
+	"sync/atomic"
+)
+
+// lazyLogger is a logger derived from the default logger by the package functions, it is
+// rebuilt from the current default when SetDefault or New replaced it, so package level
+// loggers such as `var log = logger.Named("payment")` follow the logger built in main.
+type lazyLogger struct {
+	parent *lazyLogger
+	derive func(Logger) Logger
+
+	cache atomic.Pointer[lazyCache]
+}
+
+type lazyCache struct {
+	d *defaultLogger
+	l Logger
+}
+
+func newLazyLogger(parent *lazyLogger, derive func(Logger) Logger) *lazyLogger {
+	l := &lazyLogger{parent: parent, derive: derive}
+	// resolve once so named loggers register their module right away, see GetLevels
+	l.resolve()
+	return l
+}
+
+// resolve returns the logger derived from the current default, built from its std
+// logger since the lazyLogger methods add a caller frame.
+func (l *lazyLogger) resolve() Logger {
+	d := _default.Load()
+	if c := l.cache.Load(); c != nil && c.d == d {
+		return c.l
+	}
+
+	var base Logger
+	if l.parent != nil {
+		base = l.parent.resolve()
+	} else {
+		base = d.std
+	}
+	rl := l.derive(base)
+	l.cache.Store(&lazyCache{d: d, l: rl})
+	return rl
+}
+
+func (l *lazyLogger) With(fields ...Field) Logger {
+	return newLazyLogger(l, func(p Logger) Logger { return p.With(fields...) })
+}
+
+func (l *lazyLogger) Named(name string) Logger {
+	return newLazyLogger(l, func(p Logger) Logger { return p.Named(name) })
+}
+
+func (l *lazyLogger) SetLevel(lv string) {
+	l.resolve().SetLevel(lv)
+}
+
+func (l *lazyLogger) Sync() error {
+	return l.resolve().Sync()
+}
+
+func (l *lazyLogger) Debug(msg string, fields ...Field) {
+	l.resolve().Debug(msg, fields...)
+}
+
+func (l *lazyLogger) Info(msg string, fields ...Field) {
+	l.resolve().Info(msg, fields...)
+}
+
+func (l *lazyLogger) Warn(msg string, fields ...Field) {
+	l.resolve().Warn(msg, fields...)
+}
+
+func (l *lazyLogger) Error(msg string, fields ...Field) {
+	l.resolve().Error(msg, fields...)
+}
+
+func (l *lazyLogger) Fatal(msg string, fields ...Field) {
+	l.resolve().Fatal(msg, fields...)
+}
+
+func (l *lazyLogger) Debugf(format string, args ...any) {
+	l.resolve().Debugf(format, args...)
+}
+
+func (l *lazyLogger) Infof(format string, args ...any) {
+	l.resolve().Infof(format, args...)
+}
+
+func (l *lazyLogger) Warnf(format string, args ...any) {
+	l.resolve().Warnf(format, args...)
+}
+
+func (l *lazyLogger) Errorf(format string, args ...any) {
+	l.resolve().Errorf(format, args...)
+}
+
+func (l *lazyLogger) Fatalf(format string, args ...any) {
+	l.resolve().Fatalf(format, args...)
+}
+
+// asZapLogger returns the zap logger behind l, resolving a lazyLogger.
+func asZapLogger(l Logger) (*zapLogger, bool) {
+	if ll, ok := l.(*lazyLogger); ok {
+		l = ll.resolve()
+	}
+	zl, ok := l.(*zapLogger)
+	return zl, ok
+}
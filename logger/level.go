@@ -0,0 +1,257 @@
+package logger
+
+import (
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"path"
+	"strings"
+	"sync"
+)
+
+type levelRule struct {
+	pattern string
+	level   zapcore.Level
+}
+
+// levelRegistry holds the root level and the levels of the named sub-loggers of one logger.
+type levelRegistry struct {
+	mu      sync.Mutex
+	root    zap.AtomicLevel
+	rules   []levelRule
+	modules map[string]zap.AtomicLevel
+	// min the lowest enabled level, used by the cores so any module can log below root
+	min zap.AtomicLevel
+}
+
+func newLevelRegistry(root zap.AtomicLevel) *levelRegistry {
+	return &levelRegistry{
+		root:    root,
+		modules: make(map[string]zap.AtomicLevel),
+		min:     zap.NewAtomicLevelAt(root.Level()),
+	}
+}
+
+// module returns the level of the named sub-logger, creating it if needed.
+func (r *levelRegistry) module(name string) zap.AtomicLevel {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if al, ok := r.modules[name]; ok {
+		return al
+	}
+	al := zap.NewAtomicLevelAt(r.resolve(name))
+	r.modules[name] = al
+	return al
+}
+
+// resolve returns the level of the last rule matching name, or the root level.
+func (r *levelRegistry) resolve(name string) zapcore.Level {
+	for i := len(r.rules) - 1; i >= 0; i-- {
+		if matchModule(r.rules[i].pattern, name) {
+			return r.rules[i].level
+		}
+	}
+	return r.root.Level()
+}
+
+func (r *levelRegistry) setRoot(lvl zapcore.Level) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.root.SetLevel(lvl)
+	r.refresh()
+}
+
+// set the level of the modules matching pattern, an empty level removes the rule.
+func (r *levelRegistry) set(pattern, level string) error {
+	if pattern == "" {
+		lvl, err := ParseLevel(level)
+		if err != nil {
+			return err
+		}
+		r.setRoot(lvl)
+		return nil
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("logger: invalid module pattern %q: %w", pattern, err)
+	}
+
+	var lvl zapcore.Level
+	if level != "" {
+		var err error
+		if lvl, err = ParseLevel(level); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rules := r.rules[:0:0]
+	for _, rule := range r.rules {
+		if rule.pattern != pattern {
+			rules = append(rules, rule)
+		}
+	}
+	if level != "" {
+		rules = append(rules, levelRule{pattern: pattern, level: lvl})
+	}
+	r.rules = rules
+	r.refresh()
+	return nil
+}
+
+// inherit copies the module rules and the modules of prev, the rules of r take precedence.
+func (r *levelRegistry) inherit(prev *levelRegistry) {
+	prev.mu.Lock()
+	rules := append([]levelRule(nil), prev.rules...)
+	names := make([]string, 0, len(prev.modules))
+	for name := range prev.modules {
+		names = append(names, name)
+	}
+	prev.mu.Unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, rule := range r.rules {
+		for i := 0; i < len(rules); i++ {
+			if rules[i].pattern == rule.pattern {
+				rules = append(rules[:i], rules[i+1:]...)
+				i--
+			}
+		}
+	}
+	r.rules = append(rules, r.rules...)
+	for _, name := range names {
+		if _, ok := r.modules[name]; !ok {
+			r.modules[name] = zap.NewAtomicLevelAt(r.root.Level())
+		}
+	}
+	r.refresh()
+}
+
+// refresh recomputes the module levels after a change, r.mu must be held.
+func (r *levelRegistry) refresh() {
+	min := r.root.Level()
+	for name, al := range r.modules {
+		lvl := r.resolve(name)
+		al.SetLevel(lvl)
+		if lvl < min {
+			min = lvl
+		}
+	}
+	for _, rule := range r.rules {
+		if rule.level < min {
+			min = rule.level
+		}
+	}
+	r.min.SetLevel(min)
+}
+
+// Levels describes the root level, the rules and the levels of the known modules.
+type Levels struct {
+	Level   string            `json:"level"`
+	Rules   map[string]string `json:"rules,omitempty"`
+	Modules map[string]string `json:"modules,omitempty"`
+}
+
+func (r *levelRegistry) levels() Levels {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ls := Levels{
+		Level:   r.root.Level().String(),
+		Rules:   make(map[string]string, len(r.rules)),
+		Modules: make(map[string]string, len(r.modules)),
+	}
+	for _, rule := range r.rules {
+		ls.Rules[rule.pattern] = rule.level.String()
+	}
+	for name, al := range r.modules {
+		ls.Modules[name] = al.Level().String()
+	}
+	return ls
+}
+
+// matchModule reports whether the module name matches pattern, '*' matches any characters.
+func matchModule(pattern, name string) bool {
+	if pattern == name {
+		return true
+	}
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
+
+// moduleCore filters entries with the level of a named sub-logger.
+type moduleCore struct {
+	zapcore.Core
+	level zap.AtomicLevel
+}
+
+func (c *moduleCore) Enabled(lvl zapcore.Level) bool {
+	return c.level.Enabled(lvl)
+}
+
+func (c *moduleCore) With(fields []zapcore.Field) zapcore.Core {
+	return &moduleCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c *moduleCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.level.Enabled(ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// ParseLevel parses a level name, unlike WithLevel it reports unknown names.
+func ParseLevel(lv string) (zapcore.Level, error) {
+	level, ok := loggerLevelMap[strings.ToLower(strings.TrimSpace(lv))]
+	if !ok {
+		return DefaultLevel, fmt.Errorf("logger: unknown level %q", lv)
+	}
+	return level, nil
+}
+
+// SetModuleLevel sets the level of the sub-loggers of the default logger whose name
+// matches pattern, e.g. "payment" or "rpc.*". An empty level removes the rule and an
+// empty pattern sets the root level.
+func SetModuleLevel(pattern, level string) error {
+	return setModuleLevel(Default(), pattern, level)
+}
+
+// SetLevels applies a comma separated spec such as "info,rpc.*=debug,payment=warn",
+// an entry without '=' sets the root level.
+func SetLevels(spec string) error {
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		pattern, level, ok := strings.Cut(item, "=")
+		if !ok {
+			pattern, level = "", item
+		}
+		if err := SetModuleLevel(strings.TrimSpace(pattern), strings.TrimSpace(level)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetLevels returns the levels of the default logger.
+func GetLevels() Levels {
+	if zl, ok := Default().(*zapLogger); ok && zl.levels != nil {
+		return zl.levels.levels()
+	}
+	return Levels{}
+}
+
+func setModuleLevel(l Logger, pattern, level string) error {
+	zl, ok := asZapLogger(l)
+	if !ok || zl.levels == nil {
+		return fmt.Errorf("logger: %T doesn't support module levels", l)
+	}
+	return zl.levels.set(pattern, level)
+}
@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"testing"
+)
+
+func TestNewKeepsModuleRules(t *testing.T) {
+	prev := Default()
+	defer SetDefault(prev)
+
+	New(WithDisableConsole())
+	payment := Named("payment")
+	rpc := Named("rpc").Named("client")
+	if err := SetModuleLevel("rpc.*", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	payment.SetLevel("warn")
+
+	ring := NewRingSink(10)
+	New(WithSink(ring), WithDisableConsole())
+	defer Close()
+
+	ls := GetLevels()
+	if ls.Rules["rpc.*"] != "debug" || ls.Rules["payment"] != "warn" {
+		t.Errorf("rules = %v, want rpc.*=debug and payment=warn", ls.Rules)
+	}
+	if ls.Modules["rpc.client"] != "debug" || ls.Modules["payment"] != "warn" {
+		t.Errorf("modules = %v", ls.Modules)
+	}
+
+	rpc.Debug("rpc debug")
+	payment.Info("payment info")
+	payment.Warn("payment warn")
+	if got := ring.Entries(zapcore.DebugLevel); len(got) != 2 {
+		t.Errorf("entries = %s, want rpc debug and payment warn", got)
+	}
+}
+
+func TestLevelRegistryInheritPrecedence(t *testing.T) {
+	prev := newLevelRegistry(zap.NewAtomicLevelAt(zapcore.InfoLevel))
+	_ = prev.set("a.*", "debug")
+	_ = prev.set("b", "error")
+
+	r := newLevelRegistry(zap.NewAtomicLevelAt(zapcore.WarnLevel))
+	_ = r.set("a.*", "info")
+	r.inherit(prev)
+
+	if lvl := r.module("a.x").Level(); lvl != zapcore.InfoLevel {
+		t.Errorf("a.x = %v, the rule of the new registry must win", lvl)
+	}
+	if lvl := r.module("b").Level(); lvl != zapcore.ErrorLevel {
+		t.Errorf("b = %v, want the inherited error", lvl)
+	}
+	if lvl := r.module("c").Level(); lvl != zapcore.WarnLevel {
+		t.Errorf("c = %v, want the root warn", lvl)
+	}
+}
@@ -3,6 +3,7 @@ package logger
 import (
 	"fmt"
 	"go.uber.org/zap"
+	"sync/atomic"
 )
 
 type Logger interface {
@@ -17,106 +18,141 @@ type Logger interface {
 	Warnf(format string, args ...any)
 	Errorf(format string, args ...any)
 	Fatalf(format string, args ...any)
-}
 
-func init() {
-	New()
+	// With returns a child logger carrying fields.
+	With(fields ...Field) Logger
+	// Named returns a child logger with name appended to the logger name.
+	Named(name string) Logger
+	// SetLevel changes the level of the logger and the loggers derived from it.
+	SetLevel(lv string)
+	// Sync flushes any buffered log entries.
+	Sync() error
 }
 
-func SetLevel(lv string) {
-	al.SetLevel(getLoggerLevel(lv))
+type defaultLogger struct {
+	l Logger
+	// std is l with one more caller skip, used by the package functions
+	std Logger
 }
 
-func Debug(msg string, fields ...Field) {
-	zl.Debug(msg, fields...)
-}
+var _default atomic.Pointer[defaultLogger]
 
-func Info(msg string, fields ...Field) {
-	zl.Info(msg, fields...)
+func init() {
+	New()
 }
 
-func Warn(msg string, fields ...Field) {
-	zl.Warn(msg, fields...)
+// SetDefault replaces the logger used by the package functions.
+func SetDefault(l Logger) {
+	d := &defaultLogger{l: l, std: l}
+	if zl, ok := l.(*zapLogger); ok {
+		d.std = zl.clone(zl.zl.WithOptions(zap.AddCallerSkip(1)))
+	}
+	_default.Store(d)
 }
 
-func Error(msg string, fields ...Field) {
-	zl.Error(msg, fields...)
+// Default returns the logger used by the package functions.
+func Default() Logger {
+	if d := _default.Load(); d != nil {
+		return d.l
+	}
+	return nil
 }
 
-func Fatal(msg string, fields ...Field) {
-	zl.Fatal(msg, fields...)
+func std() Logger {
+	return _default.Load().std
 }
 
-func Debugf(format string, args ...any) {
-	zl.Debug(fmt.Sprintf(format, args...))
+func SetLevel(lv string) {
+	Default().SetLevel(lv)
 }
 
-func Infof(format string, args ...any) {
-	zl.Info(fmt.Sprintf(format, args...))
+// Sync flushes any buffered log entries of the default logger.
+func Sync() error {
+	return Default().Sync()
 }
 
-func Warnf(format string, args ...any) {
-	zl.Warn(fmt.Sprintf(format, args...))
+// Close flushes buffered log entries and stops the async writers of the default logger,
+// it should be called on shutdown.
+func Close() error {
+	if c, ok := Default().(interface{ Close() error }); ok {
+		return c.Close()
+	}
+	return Default().Sync()
 }
 
-func Errorf(format string, args ...any) {
-	zl.Error(fmt.Sprintf(format, args...))
+// Dropped returns the number of entries dropped by the async writers of the default logger.
+func Dropped() uint64 {
+	if d, ok := Default().(interface{ Dropped() uint64 }); ok {
+		return d.Dropped()
+	}
+	return 0
 }
 
-func Fatalf(format string, args ...any) {
-	zl.Fatal(fmt.Sprintf(format, args...))
+func Debug(msg string, fields ...Field) {
+	std().Debug(msg, fields...)
 }
 
-func SugaredLogger() *zap.SugaredLogger {
-	return zl.Sugar()
+func Info(msg string, fields ...Field) {
+	std().Info(msg, fields...)
 }
 
-type fieldLog struct {
-	zl *zap.Logger
+func Warn(msg string, fields ...Field) {
+	std().Warn(msg, fields...)
 }
 
-func WithFields(fs ...Field) Logger {
-	return &fieldLog{
-		zl: zl.With(fs...),
-	}
+func Error(msg string, fields ...Field) {
+	std().Error(msg, fields...)
 }
 
-func (l *fieldLog) Debug(msg string, fields ...Field) {
-	l.zl.Debug(msg, fields...)
+func Fatal(msg string, fields ...Field) {
+	std().Fatal(msg, fields...)
 }
 
-func (l *fieldLog) Info(msg string, fields ...Field) {
-	l.zl.Info(msg, fields...)
+func Debugf(format string, args ...any) {
+	std().Debug(fmt.Sprintf(format, args...))
 }
 
-func (l *fieldLog) Warn(msg string, fields ...Field) {
-	l.zl.Warn(msg, fields...)
+func Infof(format string, args ...any) {
+	std().Info(fmt.Sprintf(format, args...))
 }
 
-func (l *fieldLog) Error(msg string, fields ...Field) {
-	l.zl.Error(msg, fields...)
+func Warnf(format string, args ...any) {
+	std().Warn(fmt.Sprintf(format, args...))
 }
 
-func (l *fieldLog) Fatal(msg string, fields ...Field) {
-	l.zl.Fatal(msg, fields...)
+func Errorf(format string, args ...any) {
+	std().Error(fmt.Sprintf(format, args...))
 }
 
-func (l *fieldLog) Debugf(format string, args ...any) {
-	l.zl.Debug(fmt.Sprintf(format, args...))
+func Fatalf(format string, args ...any) {
+	std().Fatal(fmt.Sprintf(format, args...))
 }
 
-func (l *fieldLog) Infof(format string, args ...any) {
-	l.zl.Info(fmt.Sprintf(format, args...))
+// SugaredLogger returns the sugared zap logger of the default logger,
+// a no-op logger if the default logger isn't backed by zap.
+func SugaredLogger() *zap.SugaredLogger {
+	if zl, ok := Default().(*zapLogger); ok {
+		return zl.zl.Sugar()
+	}
+	return zap.NewNop().Sugar()
 }
 
-func (l *fieldLog) Warnf(format string, args ...any) {
-	l.zl.Warn(fmt.Sprintf(format, args...))
+// WithFields returns a child of the default logger carrying fs, it follows the default
+// logger when it is replaced by SetDefault or New.
+func WithFields(fs ...Field) Logger {
+	return newLazyLogger(nil, func(l Logger) Logger { return l.With(fs...) })
 }
 
-func (l *fieldLog) Errorf(format string, args ...any) {
-	l.zl.Error(fmt.Sprintf(format, args...))
+// Named returns a child of the default logger with name appended to the logger name,
+// it follows the default logger when it is replaced by SetDefault or New.
+func Named(name string) Logger {
+	return newLazyLogger(nil, func(l Logger) Logger { return l.Named(name) })
 }
 
-func (l *fieldLog) Fatalf(format string, args ...any) {
-	l.zl.Fatal(fmt.Sprintf(format, args...))
+// Reopen reopens the log files of the default logger, e.g. after an external logrotate.
+func Reopen() error {
+	if r, ok := Default().(interface{ Reopen() error }); ok {
+		return r.Reopen()
+	}
+	return nil
 }
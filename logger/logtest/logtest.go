@@ -0,0 +1,140 @@
+// Package logtest provides a logger capturing entries in memory for test assertions.
+package logtest
+
+import (
+	"github.com/metaitself/xmeta/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"strings"
+	"testing"
+)
+
+// Recorder holds the entries captured by a test logger.
+type Recorder struct {
+	*observer.ObservedLogs
+	// t the test of Install, FilterLevel fails it on an unknown level
+	t testing.TB
+}
+
+// NewLogger returns a logger recording entries at or above level, "debug" if empty.
+// It panics on an unknown level.
+func NewLogger(level string) (logger.Logger, *Recorder) {
+	lvl := zapcore.DebugLevel
+	if level != "" {
+		var err error
+		if lvl, err = logger.ParseLevel(level); err != nil {
+			panic(err)
+		}
+	}
+
+	al := zap.NewAtomicLevelAt(lvl)
+	core, logs := observer.New(al)
+	return logger.NewFromZap(zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1)), al), &Recorder{ObservedLogs: logs}
+}
+
+// Install replaces the default logger with a recording one until the test ends.
+func Install(t testing.TB) *Recorder {
+	t.Helper()
+
+	l, rec := NewLogger("debug")
+	rec.t = t
+	prev := logger.Default()
+	logger.SetDefault(l)
+	t.Cleanup(func() {
+		logger.SetDefault(prev)
+	})
+	return rec
+}
+
+// FilterMessage returns the entries with the exact message.
+func (r *Recorder) FilterMessage(msg string) *Recorder {
+	return &Recorder{ObservedLogs: r.ObservedLogs.FilterMessage(msg), t: r.t}
+}
+
+// FilterMessageSnippet returns the entries whose message contains snippet.
+func (r *Recorder) FilterMessageSnippet(snippet string) *Recorder {
+	return &Recorder{ObservedLogs: r.ObservedLogs.FilterMessageSnippet(snippet), t: r.t}
+}
+
+// FilterField returns the entries carrying field.
+func (r *Recorder) FilterField(field logger.Field) *Recorder {
+	return &Recorder{ObservedLogs: r.ObservedLogs.FilterField(field), t: r.t}
+}
+
+// FilterFieldKey returns the entries carrying a field with key.
+func (r *Recorder) FilterFieldKey(key string) *Recorder {
+	return &Recorder{ObservedLogs: r.ObservedLogs.FilterFieldKey(key), t: r.t}
+}
+
+// FilterLevel returns the entries at exactly level. An unknown level fails the test of
+// Install, or panics for a recorder of NewLogger.
+func (r *Recorder) FilterLevel(level string) *Recorder {
+	lvl, err := logger.ParseLevel(level)
+	if err != nil {
+		if r.t == nil {
+			panic(err)
+		}
+		r.t.Helper()
+		r.t.Fatalf("logtest: %v", err)
+	}
+	return r.filterLevel(lvl)
+}
+
+func (r *Recorder) filterLevel(lvl zapcore.Level) *Recorder {
+	return &Recorder{ObservedLogs: r.ObservedLogs.FilterLevelExact(lvl), t: r.t}
+}
+
+// parseLevel fails t on an unknown level so a typo doesn't check the wrong level.
+func parseLevel(t testing.TB, level string) zapcore.Level {
+	t.Helper()
+
+	lvl, err := logger.ParseLevel(level)
+	if err != nil {
+		t.Fatalf("logtest: %v", err)
+	}
+	return lvl
+}
+
+// Messages returns the messages of all entries.
+func (r *Recorder) Messages() []string {
+	entries := r.All()
+	msgs := make([]string, len(entries))
+	for i, e := range entries {
+		msgs[i] = e.Message
+	}
+	return msgs
+}
+
+// AssertLogged fails the test unless an entry with level and message was logged.
+func (r *Recorder) AssertLogged(t testing.TB, level, msg string) {
+	t.Helper()
+
+	if r.filterLevel(parseLevel(t, level)).FilterMessage(msg).Len() == 0 {
+		t.Errorf("expected %s entry %q to be logged, got:\n%s", level, msg, r.dump())
+	}
+}
+
+// AssertNotLogged fails the test if an entry with level and message was logged.
+func (r *Recorder) AssertNotLogged(t testing.TB, level, msg string) {
+	t.Helper()
+
+	if r.filterLevel(parseLevel(t, level)).FilterMessage(msg).Len() != 0 {
+		t.Errorf("expected %s entry %q not to be logged", level, msg)
+	}
+}
+
+func (r *Recorder) dump() string {
+	var b strings.Builder
+	for _, e := range r.All() {
+		b.WriteString("  ")
+		b.WriteString(e.Level.String())
+		b.WriteString(": ")
+		b.WriteString(e.Message)
+		b.WriteByte('\n')
+	}
+	if b.Len() == 0 {
+		return "  (no entries)\n"
+	}
+	return b.String()
+}
@@ -0,0 +1,153 @@
+package logtest
+
+import (
+	"fmt"
+	"github.com/metaitself/xmeta/logger"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// fakeTB records the failures of the helpers under test, Fatalf stops the goroutine
+// like testing.T does.
+type fakeTB struct {
+	testing.TB
+	mu     sync.Mutex
+	errors []string
+	fatal  bool
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) Fatalf(format string, args ...any) {
+	f.Errorf(format, args...)
+	f.mu.Lock()
+	f.fatal = true
+	f.mu.Unlock()
+	runtime.Goexit()
+}
+
+// run calls fn in its own goroutine so Fatalf can stop it.
+func (f *fakeTB) run(fn func()) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	<-done
+}
+
+func TestInstallRestoresDefault(t *testing.T) {
+	prev := logger.Default()
+	t.Run("installed", func(t *testing.T) {
+		rec := Install(t)
+		if logger.Default() == prev {
+			t.Fatal("the default logger wasn't replaced")
+		}
+		logger.Info("hello", logger.String("k", "v"))
+		rec.AssertLogged(t, "info", "hello")
+	})
+	if logger.Default() != prev {
+		t.Fatal("the previous default logger wasn't restored")
+	}
+}
+
+func TestRecorderFilters(t *testing.T) {
+	rec := Install(t)
+	logger.Debug("debug entry")
+	logger.Info("request done", logger.Int("status", 200))
+	logger.Warn("request slow", logger.Int("status", 200))
+	logger.Error("request failed", logger.Int("status", 500))
+
+	if n := rec.FilterLevel("warn").Len(); n != 1 {
+		t.Errorf("warn entries = %d, want 1", n)
+	}
+	if n := rec.FilterMessage("request done").Len(); n != 1 {
+		t.Errorf("entries with the message = %d, want 1", n)
+	}
+	if n := rec.FilterMessageSnippet("request").Len(); n != 3 {
+		t.Errorf("entries with the snippet = %d, want 3", n)
+	}
+	if n := rec.FilterField(logger.Int("status", 200)).Len(); n != 2 {
+		t.Errorf("entries with status 200 = %d, want 2", n)
+	}
+	if n := rec.FilterFieldKey("status").FilterLevel("error").Len(); n != 1 {
+		t.Errorf("error entries with status = %d, want 1", n)
+	}
+	want := []string{"debug entry", "request done", "request slow", "request failed"}
+	if got := rec.Messages(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("messages = %v, want %v", got, want)
+	}
+
+	rec.AssertNotLogged(t, "error", "request done")
+}
+
+func TestNewLoggerLevel(t *testing.T) {
+	l, rec := NewLogger("warn")
+	l.Info("dropped")
+	l.Warn("kept")
+	if got := rec.Messages(); len(got) != 1 || got[0] != "kept" {
+		t.Fatalf("messages = %v, want [kept]", got)
+	}
+}
+
+func TestAssertFailures(t *testing.T) {
+	_, rec := NewLogger("")
+
+	ft := &fakeTB{TB: t}
+	ft.run(func() { rec.AssertLogged(ft, "info", "missing") })
+	if len(ft.errors) != 1 || ft.fatal {
+		t.Errorf("AssertLogged failures = %v, want one error", ft.errors)
+	}
+}
+
+func TestUnknownLevel(t *testing.T) {
+	_, rec := NewLogger("")
+
+	ft := &fakeTB{TB: t}
+	ft.run(func() { rec.AssertLogged(ft, "warning!", "msg") })
+	if !ft.fatal {
+		t.Error("AssertLogged accepted an unknown level")
+	}
+
+	ft = &fakeTB{TB: t}
+	ft.run(func() { rec.AssertNotLogged(ft, "warning!", "msg") })
+	if !ft.fatal {
+		t.Error("AssertNotLogged accepted an unknown level")
+	}
+
+	// a recorder of Install fails its test
+	ft = &fakeTB{TB: t}
+	ft.run(func() {
+		installed := &Recorder{ObservedLogs: rec.ObservedLogs, t: ft}
+		installed.FilterLevel("warning!")
+	})
+	if !ft.fatal {
+		t.Error("FilterLevel accepted an unknown level")
+	}
+
+	// a recorder of NewLogger has no test to fail
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("FilterLevel didn't panic on an unknown level")
+			}
+		}()
+		rec.FilterLevel("warning!")
+	}()
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("NewLogger didn't panic on an unknown level")
+			}
+		}()
+		NewLogger("warning!")
+	}()
+}
@@ -0,0 +1,80 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"github.com/metaitself/xmeta/metaerror"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"sort"
+)
+
+// Err logs err under the "error" key, a MetaError in the chain is written as an object, see MetaErr.
+func Err(err error) Field {
+	return NamedErr("error", err)
+}
+
+// NamedErr is Err with a custom key.
+func NamedErr(key string, err error) Field {
+	var me *metaerror.MetaError
+	if err != nil && errors.As(err, &me) {
+		return NamedMetaErr(key, err)
+	}
+	return zap.NamedError(key, err)
+}
+
+// MetaErr logs err as an object with the code, status, reason, message, metadata and
+// cause of the MetaError, plus the stack when err carries one. Errors which aren't a
+// MetaError are converted with metaerror.FromError.
+func MetaErr(err error) Field {
+	return NamedMetaErr("error", err)
+}
+
+// NamedMetaErr is MetaErr with a custom key.
+func NamedMetaErr(key string, err error) Field {
+	if err == nil {
+		return zap.Skip()
+	}
+	return zap.Object(key, metaErrorObject{err: err, me: metaerror.FromError(err)})
+}
+
+type metaErrorObject struct {
+	err error
+	me  *metaerror.MetaError
+}
+
+func (o metaErrorObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddInt32("code", o.me.Code)
+	enc.AddInt32("status", o.me.Status)
+	if o.me.Reason != "" {
+		enc.AddString("reason", o.me.Reason)
+	}
+	enc.AddString("msg", o.me.Msg)
+	if len(o.me.Metadata) > 0 {
+		_ = enc.AddObject("metadata", stringMap(o.me.Metadata))
+	}
+	if o.me.Cause != "" {
+		enc.AddString("cause", o.me.Cause)
+	}
+	// errors carrying a stack, e.g. github.com/pkg/errors, print it with %+v
+	if f, ok := o.err.(fmt.Formatter); ok {
+		if stack := fmt.Sprintf("%+v", f); stack != o.err.Error() {
+			enc.AddString("stack", stack)
+		}
+	}
+	return nil
+}
+
+type stringMap map[string]string
+
+func (m stringMap) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		enc.AddString(k, m[k])
+	}
+	return nil
+}
@@ -2,10 +2,7 @@ package logger
 
 import (
 	"go.uber.org/zap/zapcore"
-	"gopkg.in/natefinch/lumberjack.v2"
 	"io"
-	"os"
-	"path/filepath"
 )
 
 // Option custom setup config
@@ -19,6 +16,52 @@ type option struct {
 	callerSkip     int
 	addCaller      bool
 	disableConsole bool
+	async          bool
+	asyncSize      int
+	asyncBlock     bool
+	metadataKeys   []string
+	levelFiles     []levelFile
+	reopenOnSIGHUP bool
+	sampling       *SamplingConfig
+	redactor       *redactor
+	sinks          []Sink
+
+	// err the first error raised by an option, returned by NewLogger
+	err error
+}
+
+type levelFile struct {
+	level zapcore.Level
+	w     io.Writer
+}
+
+func (opt *option) setErr(err error) {
+	if opt.err == nil {
+		opt.err = err
+	}
+}
+
+// close releases the files and sinks opened by the options, when NewLogger fails.
+func (opt *option) close() {
+	closeWriter(opt.file)
+	for _, lf := range opt.levelFiles {
+		closeWriter(lf.w)
+	}
+	for _, s := range opt.sinks {
+		_ = s.Close()
+	}
+}
+
+// setFile replaces the log file, closing the one set by a previous option.
+func (opt *option) setFile(w io.Writer) {
+	closeWriter(opt.file)
+	opt.file = w
+}
+
+func closeWriter(w io.Writer) {
+	if c, ok := w.(io.Closer); ok {
+		_ = c.Close()
+	}
 }
 
 // WithLevel only greater than 'level' will output
@@ -40,39 +83,58 @@ func AddCallerSkip(skip int) Option {
 	}
 }
 
-// WithFileP write log to some file
+// WithFileP write log to some file, NewLogger returns the error if the file can't be opened
 func WithFileP(file string) Option {
-	dir := filepath.Dir(file)
-	if err := os.MkdirAll(dir, 0766); err != nil {
-		panic(err)
-	}
-
-	f, err := os.OpenFile(file, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0766)
-	if err != nil {
-		panic(err)
-	}
-
 	return func(opt *option) {
-		opt.file = zapcore.Lock(f)
+		f, err := openReopenFile(file)
+		if err != nil {
+			opt.setErr(err)
+			return
+		}
+		opt.setFile(f)
 	}
 }
 
 // WithFileRotationP write log to some file with rotation
 func WithFileRotationP(file string) Option {
-	dir := filepath.Dir(file)
-	if err := os.MkdirAll(dir, 0766); err != nil {
-		panic(err)
+	return WithRotation(DefaultRotationConfig(file))
+}
+
+// WithRotation write log to a file rotated by size and optionally by time
+func WithRotation(cfg RotationConfig) Option {
+	return func(opt *option) {
+		w, err := newRotationWriter(cfg)
+		if err != nil {
+			opt.setErr(err)
+			return
+		}
+		opt.setFile(w)
 	}
+}
 
+// WithLevelRotation additionally write the entries at or above level to a separate file,
+// e.g. WithLevelRotation("error", RotationConfig{Filename: "logs/error.log"})
+func WithLevelRotation(level string, cfg RotationConfig) Option {
 	return func(opt *option) {
-		opt.file = &lumberjack.Logger{
-			Filename:   file, // 文件路径
-			MaxSize:    128,  // 单个文件最大尺寸，默认单位 M
-			MaxBackups: 300,  // 最多保留 300 个备份
-			MaxAge:     30,   // 最大时间，默认单位 day
-			LocalTime:  true, // 使用本地时间
-			Compress:   true, // 是否压缩 disabled by default
+		lvl, err := ParseLevel(level)
+		if err != nil {
+			opt.setErr(err)
+			return
+		}
+		w, err := newRotationWriter(cfg)
+		if err != nil {
+			opt.setErr(err)
+			return
 		}
+		opt.levelFiles = append(opt.levelFiles, levelFile{level: lvl, w: w})
+	}
+}
+
+// WithReopenOnSIGHUP reopen the log files when the process receives SIGHUP,
+// for use with an external logrotate
+func WithReopenOnSIGHUP() Option {
+	return func(opt *option) {
+		opt.reopenOnSIGHUP = true
 	}
 }
 
@@ -95,3 +157,67 @@ func WithEncode(encode string) Option {
 		opt.encode = encode
 	}
 }
+
+// WithAsync write log through a bounded queue of size entries from a background goroutine,
+// when the queue is full the entry is dropped, or the caller blocks if block is true.
+func WithAsync(size int, block bool) Option {
+	return func(opt *option) {
+		opt.async = true
+		opt.asyncSize = size
+		opt.asyncBlock = block
+	}
+}
+
+// WithMetadataKeys the keys of metadata.FromContext added to entries logged with a context.
+func WithMetadataKeys(keys ...string) Option {
+	return func(opt *option) {
+		opt.metadataKeys = keys
+	}
+}
+
+// WithSampling limit repeated entries per message and level, see SamplingConfig
+func WithSampling(cfg SamplingConfig) Option {
+	return func(opt *option) {
+		cfg = cfg.withDefaults()
+		if err := cfg.validate(); err != nil {
+			opt.setErr(err)
+			return
+		}
+		opt.sampling = &cfg
+	}
+}
+
+// WithRedaction mask sensitive field values and message parts, see RedactionConfig
+func WithRedaction(cfg RedactionConfig) Option {
+	return func(opt *option) {
+		r, err := newRedactor(cfg)
+		if err != nil {
+			opt.setErr(err)
+			return
+		}
+		opt.redactor = r
+	}
+}
+
+// WithSink additionally write JSON encoded entries to sink, the sink is closed with the logger
+func WithSink(sink Sink) Option {
+	return func(opt *option) {
+		opt.sinks = append(opt.sinks, sink)
+	}
+}
+
+// withSinkFunc creates the sink when the option is applied, so a sink isn't dialed by
+// options that are never passed to NewLogger.
+func withSinkFunc(newSink func() (Sink, error)) Option {
+	return func(opt *option) {
+		if opt.err != nil {
+			return
+		}
+		sink, err := newSink()
+		if err != nil {
+			opt.setErr(err)
+			return
+		}
+		opt.sinks = append(opt.sinks, sink)
+	}
+}
@@ -0,0 +1,233 @@
+package logger
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go.uber.org/zap/zapcore"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaskMode how a sensitive value is masked.
+type MaskMode string
+
+const (
+	// MaskFull replaces the whole value
+	MaskFull MaskMode = "full"
+	// MaskPartial keeps the last four characters
+	MaskPartial MaskMode = "partial"
+	// MaskHash replaces the value with an HMAC-SHA256 prefix keyed with RedactionConfig.HashKey,
+	// so equal values can still be correlated. A plain hash of a phone number is easily
+	// reversed, without a key the value is fully masked.
+	MaskHash MaskMode = "hash"
+)
+
+const maskString = "******"
+
+// Redactor is implemented by values which know how to hide their sensitive parts,
+// values logged with Any are replaced with the result of Redact.
+type Redactor interface {
+	Redact() any
+}
+
+// RedactPattern masks the parts of the message and string fields matching Pattern. If the
+// pattern has a capturing group only the first group is masked, so boundaries can be
+// matched with groups such as (?:^|\D) which RE2 has no lookaround for.
+type RedactPattern struct {
+	Pattern string   `json:"pattern" yaml:"pattern"`
+	Mode    MaskMode `json:"mode" yaml:"mode"`
+}
+
+// RedactionConfig the redaction rules.
+type RedactionConfig struct {
+	// Fields masks the values of the fields with these keys, keys are case-insensitive
+	Fields map[string]MaskMode `json:"fields" yaml:"fields"`
+	// Patterns masks matches in the message and string field values
+	Patterns []RedactPattern `json:"patterns" yaml:"patterns"`
+	// HashKey the secret key of MaskHash, keep it out of the logs
+	HashKey string `json:"hash_key" yaml:"hash_key"`
+}
+
+const (
+	// PhonePattern matches mainland China mobile numbers which aren't part of a longer number
+	PhonePattern = `(?:^|\D)(1[3-9]\d{9})(?:\D|$)`
+	// EmailPattern matches email addresses
+	EmailPattern = `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`
+)
+
+type compiledPattern struct {
+	re   *regexp.Regexp
+	mode MaskMode
+}
+
+type redactor struct {
+	fields   map[string]MaskMode
+	patterns []compiledPattern
+	key      []byte
+}
+
+func newRedactor(cfg RedactionConfig) (*redactor, error) {
+	r := &redactor{fields: make(map[string]MaskMode, len(cfg.Fields))}
+	if cfg.HashKey != "" {
+		r.key = []byte(cfg.HashKey)
+	}
+	for k, mode := range cfg.Fields {
+		if err := mode.validate(); err != nil {
+			return nil, err
+		}
+		r.fields[strings.ToLower(k)] = mode
+	}
+	for _, p := range cfg.Patterns {
+		if err := p.Mode.validate(); err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("logger: invalid redaction pattern %q: %w", p.Pattern, err)
+		}
+		r.patterns = append(r.patterns, compiledPattern{re: re, mode: p.Mode})
+	}
+	return r, nil
+}
+
+func (m MaskMode) validate() error {
+	switch m {
+	case MaskFull, MaskPartial, MaskHash:
+		return nil
+	}
+	return fmt.Errorf("logger: unknown mask mode %q", m)
+}
+
+// Mask masks s with mode, MaskHash values are fully masked, see MaskWithKey.
+func Mask(s string, mode MaskMode) string {
+	return MaskWithKey(s, mode, nil)
+}
+
+// MaskWithKey masks s with mode, key is the HMAC key of MaskHash.
+func MaskWithKey(s string, mode MaskMode, key []byte) string {
+	switch mode {
+	case MaskPartial:
+		n := utf8.RuneCountInString(s)
+		if n <= 4 {
+			return maskString
+		}
+		rs := []rune(s)
+		return strings.Repeat("*", n-4) + string(rs[n-4:])
+	case MaskHash:
+		if len(key) == 0 {
+			return maskString
+		}
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(s))
+		return "hmac:" + hex.EncodeToString(mac.Sum(nil)[:8])
+	}
+	return maskString
+}
+
+func (r *redactor) text(s string) string {
+	for _, p := range r.patterns {
+		if p.re.NumSubexp() == 0 {
+			mode := p.mode
+			s = p.re.ReplaceAllStringFunc(s, func(m string) string {
+				return MaskWithKey(m, mode, r.key)
+			})
+			continue
+		}
+		s = maskGroup(p.re, s, p.mode, r.key)
+	}
+	return s
+}
+
+// maskGroup masks the first group of each match, the search resumes right after the
+// group so a boundary consumed by one match can start the next.
+func maskGroup(re *regexp.Regexp, s string, mode MaskMode, key []byte) string {
+	var b strings.Builder
+	pos := 0
+	for pos <= len(s) {
+		loc := re.FindStringSubmatchIndex(s[pos:])
+		if loc == nil {
+			break
+		}
+		start, end := loc[2], loc[3]
+		if start < 0 {
+			// the group didn't participate, skip the match
+			if loc[1] == 0 {
+				break
+			}
+			b.WriteString(s[pos : pos+loc[1]])
+			pos += loc[1]
+			continue
+		}
+		b.WriteString(s[pos : pos+start])
+		b.WriteString(MaskWithKey(s[pos+start:pos+end], mode, key))
+		if end == start && end == 0 {
+			// an empty group at the start can't advance the search
+			break
+		}
+		pos += end
+	}
+	if pos < len(s) {
+		b.WriteString(s[pos:])
+	}
+	return b.String()
+}
+
+func (r *redactor) field(f Field) Field {
+	if mode, ok := r.fields[strings.ToLower(f.Key)]; ok {
+		return String(f.Key, MaskWithKey(fieldString(f), mode, r.key))
+	}
+	if rd, ok := f.Interface.(Redactor); ok {
+		return Any(f.Key, rd.Redact())
+	}
+	if f.Type == zapcore.StringType && len(r.patterns) > 0 {
+		f.String = r.text(f.String)
+	}
+	return f
+}
+
+func (r *redactor) redactFields(fields []Field) []Field {
+	if len(fields) == 0 {
+		return fields
+	}
+	out := make([]Field, len(fields))
+	for i, f := range fields {
+		out[i] = r.field(f)
+	}
+	return out
+}
+
+// fieldString returns the value of f as a string.
+func fieldString(f Field) string {
+	if f.Type == zapcore.StringType {
+		return f.String
+	}
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+	return fmt.Sprint(enc.Fields[f.Key])
+}
+
+// redactCore masks the message and fields before they reach the wrapped core,
+// it must wrap the leaf cores since a Tee writes to all of its cores.
+type redactCore struct {
+	zapcore.Core
+	r *redactor
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.r.redactFields(fields)), r: c.r}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Core.Enabled(ent.Level) {
+		return ce
+	}
+	ent.Message = c.r.text(ent.Message)
+	return ce.AddCore(ent, c)
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, c.r.redactFields(fields))
+}
@@ -0,0 +1,56 @@
+package logger
+
+import "testing"
+
+func TestRedactPhonePattern(t *testing.T) {
+	r, err := newRedactor(RedactionConfig{Patterns: []RedactPattern{{Pattern: PhonePattern, Mode: MaskFull}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"13812345678", "******"},
+		{"call 13812345678 now", "call ****** now"},
+		{"13812345678,13912345678", "******,******"},
+		{"ts=1713456789012", "ts=1713456789012"},
+		{"id 213812345678", "id 213812345678"},
+		{"138123456789", "138123456789"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := r.text(tt.in); got != tt.want {
+			t.Errorf("text(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMaskHash(t *testing.T) {
+	if got := Mask("13812345678", MaskHash); got != maskString {
+		t.Errorf("Mask without a key = %q, want %q", got, maskString)
+	}
+
+	a := MaskWithKey("13812345678", MaskHash, []byte("k1"))
+	if a != MaskWithKey("13812345678", MaskHash, []byte("k1")) {
+		t.Error("equal values with the same key must hash the same")
+	}
+	if a == MaskWithKey("13812345678", MaskHash, []byte("k2")) {
+		t.Error("different keys must hash differently")
+	}
+	if a == MaskWithKey("13912345678", MaskHash, []byte("k1")) {
+		t.Error("different values must hash differently")
+	}
+	if len(a) != len("hmac:")+16 || a[:5] != "hmac:" {
+		t.Errorf("hash = %q, want hmac: and 16 hex digits", a)
+	}
+
+	r, err := newRedactor(RedactionConfig{HashKey: "k1", Fields: map[string]MaskMode{"phone": MaskHash}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.field(String("Phone", "13812345678")).String; got != a {
+		t.Errorf("field = %q, want %q", got, a)
+	}
+}
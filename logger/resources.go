@@ -0,0 +1,80 @@
+package logger
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// resources the writers owned by a logger and the loggers derived from it.
+type resources struct {
+	asyncs []*AsyncWriteSyncer
+	files  []io.Writer
+	sinks  []Sink
+
+	// done is closed when the logger is closed, to stop its goroutines
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func newResources() *resources {
+	return &resources{done: make(chan struct{})}
+}
+
+func (r *resources) reopen() error {
+	var err error
+	for _, f := range r.files {
+		if ro, ok := f.(interface{ Reopen() error }); ok {
+			if rerr := ro.Reopen(); rerr != nil && err == nil {
+				err = rerr
+			}
+		}
+	}
+	return err
+}
+
+func (r *resources) watchSIGHUP() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-ch:
+				if err := r.reopen(); err != nil {
+					_, _ = os.Stderr.WriteString("logger: reopen log files: " + err.Error() + "\n")
+				}
+			case <-r.done:
+				return
+			}
+		}
+	}()
+}
+
+func (r *resources) close() error {
+	r.closeOnce.Do(func() {
+		close(r.done)
+		for _, a := range r.asyncs {
+			if err := a.Close(); err != nil && r.closeErr == nil {
+				r.closeErr = err
+			}
+		}
+		for _, s := range r.sinks {
+			if err := s.Close(); err != nil && r.closeErr == nil {
+				r.closeErr = err
+			}
+		}
+		for _, f := range r.files {
+			if c, ok := f.(io.Closer); ok {
+				if err := c.Close(); err != nil && r.closeErr == nil {
+					r.closeErr = err
+				}
+			}
+		}
+	})
+	return r.closeErr
+}
@@ -0,0 +1,189 @@
+package logger
+
+import (
+	"fmt"
+	"gopkg.in/natefinch/lumberjack.v2"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// RotationInterval the period of time based rotation.
+type RotationInterval string
+
+const (
+	RotateNone   RotationInterval = ""
+	RotateHourly RotationInterval = "hourly"
+	RotateDaily  RotationInterval = "daily"
+)
+
+// RotationConfig the rotation of a log file, see lumberjack.Logger for the size based fields.
+type RotationConfig struct {
+	// Filename the file to write logs to, backups are kept in the same directory
+	Filename string `json:"filename" yaml:"filename"`
+	// MaxSize the maximum size in megabytes of the file before it gets rotated
+	MaxSize int `json:"max_size" yaml:"max_size"`
+	// MaxAge the maximum number of days to retain old files
+	MaxAge int `json:"max_age" yaml:"max_age"`
+	// MaxBackups the maximum number of old files to retain
+	MaxBackups int `json:"max_backups" yaml:"max_backups"`
+	// LocalTime use the local time in backup file names instead of UTC
+	LocalTime bool `json:"local_time" yaml:"local_time"`
+	// Compress gzip the rotated files
+	Compress bool `json:"compress" yaml:"compress"`
+	// Interval rotates the file every hour or day in addition to the size limit
+	Interval RotationInterval `json:"interval" yaml:"interval"`
+}
+
+// DefaultRotationConfig returns the rotation used by WithFileRotationP.
+func DefaultRotationConfig(file string) RotationConfig {
+	return RotationConfig{
+		Filename:   file, // 文件路径
+		MaxSize:    128,  // 单个文件最大尺寸，默认单位 M
+		MaxBackups: 300,  // 最多保留 300 个备份
+		MaxAge:     30,   // 最大时间，默认单位 day
+		LocalTime:  true, // 使用本地时间
+		Compress:   true, // 是否压缩 disabled by default
+	}
+}
+
+// newRotationWriter creates the directory of the file and returns the rotating writer.
+func newRotationWriter(cfg RotationConfig) (*rotationWriter, error) {
+	if cfg.Filename == "" {
+		return nil, fmt.Errorf("logger: rotation filename is required")
+	}
+	switch cfg.Interval {
+	case RotateNone, RotateHourly, RotateDaily:
+	default:
+		return nil, fmt.Errorf("logger: unknown rotation interval %q", cfg.Interval)
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.Filename), 0766); err != nil {
+		return nil, err
+	}
+
+	w := &rotationWriter{
+		lj: &lumberjack.Logger{
+			Filename:   cfg.Filename,
+			MaxSize:    cfg.MaxSize,
+			MaxAge:     cfg.MaxAge,
+			MaxBackups: cfg.MaxBackups,
+			LocalTime:  cfg.LocalTime,
+			Compress:   cfg.Compress,
+		},
+		interval:  cfg.Interval,
+		localTime: cfg.LocalTime,
+	}
+	w.next = w.nextRotation(time.Now())
+	return w, nil
+}
+
+// rotationWriter adds time based rotation to lumberjack.
+type rotationWriter struct {
+	mu        sync.Mutex
+	lj        *lumberjack.Logger
+	interval  RotationInterval
+	localTime bool
+	next      time.Time
+}
+
+func (w *rotationWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.next.IsZero() {
+		if now := time.Now(); !now.Before(w.next) {
+			w.next = w.nextRotation(now)
+			if err := w.lj.Rotate(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return w.lj.Write(p)
+}
+
+func (w *rotationWriter) Sync() error {
+	return nil
+}
+
+// Reopen closes the current file, the next write opens the file again.
+func (w *rotationWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.lj.Close()
+}
+
+func (w *rotationWriter) Close() error {
+	return w.Reopen()
+}
+
+func (w *rotationWriter) nextRotation(now time.Time) time.Time {
+	if !w.localTime {
+		now = now.UTC()
+	}
+	switch w.interval {
+	case RotateHourly:
+		// Truncate works on absolute time, it would miss the hour of zones with a half hour offset
+		y, m, d := now.Date()
+		return time.Date(y, m, d, now.Hour()+1, 0, 0, 0, now.Location())
+	case RotateDaily:
+		y, m, d := now.Date()
+		return time.Date(y, m, d+1, 0, 0, 0, 0, now.Location())
+	}
+	return time.Time{}
+}
+
+// reopenFile a plain log file which can be reopened after an external rotation.
+type reopenFile struct {
+	mu   sync.Mutex
+	name string
+	f    *os.File
+}
+
+func openReopenFile(name string) (*reopenFile, error) {
+	if err := os.MkdirAll(filepath.Dir(name), 0766); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0766)
+	if err != nil {
+		return nil, err
+	}
+	return &reopenFile{name: name, f: f}, nil
+}
+
+func (r *reopenFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.f.Write(p)
+}
+
+func (r *reopenFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.f.Sync()
+}
+
+// Reopen opens the file again, e.g. after logrotate moved it away.
+func (r *reopenFile) Reopen() error {
+	f, err := os.OpenFile(r.name, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0766)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	old := r.f
+	r.f = f
+	r.mu.Unlock()
+
+	return old.Close()
+}
+
+func (r *reopenFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.f.Close()
+}
@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"go.uber.org/zap/zapcore"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotationNextHourHalfHourZone(t *testing.T) {
+	ist := time.FixedZone("IST", 5*3600+1800)
+	w := &rotationWriter{interval: RotateHourly, localTime: true}
+
+	got := w.nextRotation(time.Date(2024, 1, 2, 10, 45, 0, 0, ist))
+	if want := time.Date(2024, 1, 2, 11, 0, 0, 0, ist); !got.Equal(want) {
+		t.Fatalf("next rotation = %v, want %v", got, want)
+	}
+	got = w.nextRotation(time.Date(2024, 1, 2, 23, 10, 0, 0, ist))
+	if want := time.Date(2024, 1, 3, 0, 0, 0, 0, ist); !got.Equal(want) {
+		t.Fatalf("next rotation = %v, want %v", got, want)
+	}
+}
+
+func TestRotationNextDay(t *testing.T) {
+	w := &rotationWriter{interval: RotateDaily}
+	got := w.nextRotation(time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC))
+	if want := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("next rotation = %v, want %v", got, want)
+	}
+}
+
+type closeSink struct{ closed bool }
+
+func (s *closeSink) WriteEntry(_ zapcore.Entry, _ []byte) error { return nil }
+func (s *closeSink) Sync() error                                { return nil }
+func (s *closeSink) Close() error                               { s.closed = true; return nil }
+
+func TestNewLoggerClosesOnOptionError(t *testing.T) {
+	sink := &closeSink{}
+	_, err := NewLogger(WithSink(sink), WithLevelRotation("nope", RotationConfig{Filename: filepath.Join(t.TempDir(), "x.log")}))
+	if err == nil {
+		t.Fatal("expected an error for an unknown level")
+	}
+	if !sink.closed {
+		t.Fatal("sink was not closed")
+	}
+}
@@ -0,0 +1,164 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"go.uber.org/zap/zapcore"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SamplingRule logs the first First entries of a key per tick, then every Thereafter-th,
+// a Thereafter of 0 drops the rest of the tick. A rule with both at 0 would drop every
+// entry, it is rejected for an override and replaced by 100/100 for the default rule.
+type SamplingRule struct {
+	First      int `json:"first" yaml:"first"`
+	Thereafter int `json:"thereafter" yaml:"thereafter"`
+}
+
+// SamplingConfig limits repeated entries, entries are keyed by level and message.
+type SamplingConfig struct {
+	// Tick the sampling period, one second by default
+	Tick         ConfigDuration `json:"tick" yaml:"tick"`
+	SamplingRule `yaml:",inline"`
+	// Overrides per message rules, e.g. to never sample a critical message
+	Overrides map[string]SamplingRule `json:"overrides" yaml:"overrides"`
+	// ReportInterval how often the number of dropped entries is logged, one minute by
+	// default, a negative interval disables the report
+	ReportInterval ConfigDuration `json:"report_interval" yaml:"report_interval"`
+}
+
+// Default sampling values, the same as zap.NewProductionConfig.
+const (
+	DefaultSamplingFirst          = 100
+	DefaultSamplingThereafter     = 100
+	DefaultSamplingReportInterval = time.Minute
+)
+
+func (r SamplingRule) validate() error {
+	if r.First < 0 || r.Thereafter < 0 {
+		return errors.New("logger: sampling values must not be negative")
+	}
+	if r.First == 0 && r.Thereafter == 0 {
+		return errors.New("logger: a sampling rule with first and thereafter 0 drops every entry")
+	}
+	return nil
+}
+
+// validate checks cfg after withDefaults.
+func (c SamplingConfig) validate() error {
+	if c.Tick < 0 {
+		return errors.New("logger: negative sampling tick")
+	}
+	if err := c.SamplingRule.validate(); err != nil {
+		return err
+	}
+	for msg, rule := range c.Overrides {
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("sampling override %q: %w", msg, err)
+		}
+	}
+	return nil
+}
+
+func (c SamplingConfig) withDefaults() SamplingConfig {
+	if c.Tick == 0 {
+		c.Tick = ConfigDuration(time.Second)
+	}
+	if c.First == 0 && c.Thereafter == 0 {
+		c.First, c.Thereafter = DefaultSamplingFirst, DefaultSamplingThereafter
+	}
+	if c.ReportInterval == 0 {
+		c.ReportInterval = ConfigDuration(DefaultSamplingReportInterval)
+	}
+	return c
+}
+
+type samplerKey struct {
+	level zapcore.Level
+	msg   string
+}
+
+type sampler struct {
+	cfg SamplingConfig
+
+	mu      sync.Mutex
+	resetAt time.Time
+	counts  map[samplerKey]int
+
+	dropped atomic.Uint64
+}
+
+func newSampler(cfg SamplingConfig) *sampler {
+	return &sampler{cfg: cfg, counts: make(map[samplerKey]int)}
+}
+
+// allow reports whether the entry is logged, dropped entries are counted.
+func (s *sampler) allow(ent zapcore.Entry) bool {
+	rule, ok := s.cfg.Overrides[ent.Message]
+	if !ok {
+		rule = s.cfg.SamplingRule
+	}
+
+	s.mu.Lock()
+	if !ent.Time.Before(s.resetAt) {
+		// drop all the counters of the previous tick, so the map doesn't grow with unique messages
+		clear(s.counts)
+		s.resetAt = ent.Time.Add(time.Duration(s.cfg.Tick))
+	}
+	key := samplerKey{level: ent.Level, msg: ent.Message}
+	n := s.counts[key] + 1
+	s.counts[key] = n
+	s.mu.Unlock()
+
+	if n <= rule.First || (rule.Thereafter > 0 && (n-rule.First)%rule.Thereafter == 0) {
+		return true
+	}
+	s.dropped.Add(1)
+	return false
+}
+
+// report logs the number of entries dropped since the last report until done is closed.
+func (s *sampler) report(core zapcore.Core, done <-chan struct{}) {
+	if s.cfg.ReportInterval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(s.cfg.ReportInterval))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				n := s.dropped.Swap(0)
+				if n == 0 {
+					continue
+				}
+				ent := zapcore.Entry{Level: zapcore.WarnLevel, Time: time.Now(), Message: "log entries dropped by sampling"}
+				if ce := core.Check(ent, nil); ce != nil {
+					ce.Write(Uint64("dropped", n), Duration("interval", time.Duration(s.cfg.ReportInterval)))
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
+// samplerCore drops the entries rejected by the sampler.
+type samplerCore struct {
+	zapcore.Core
+	s *sampler
+}
+
+func (c *samplerCore) With(fields []zapcore.Field) zapcore.Core {
+	return &samplerCore{Core: c.Core.With(fields), s: c.s}
+}
+
+func (c *samplerCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Core.Enabled(ent.Level) || !c.s.allow(ent) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
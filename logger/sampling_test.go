@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"go.uber.org/zap/zapcore"
+	"testing"
+	"time"
+)
+
+func TestSamplingDefaults(t *testing.T) {
+	cfg := SamplingConfig{Overrides: map[string]SamplingRule{"x": {First: 1}}}.withDefaults()
+	if cfg.Tick != ConfigDuration(time.Second) || cfg.First != 100 || cfg.Thereafter != 100 || cfg.ReportInterval != ConfigDuration(time.Minute) {
+		t.Errorf("withDefaults() = %+v", cfg)
+	}
+
+	s := newSampler(cfg)
+	ent := zapcore.Entry{Level: zapcore.InfoLevel, Time: time.Now(), Message: "m"}
+	logged := 0
+	for i := 0; i < 300; i++ {
+		if s.allow(ent) {
+			logged++
+		}
+	}
+	// the first 100, then the 200th and the 300th
+	if logged != 102 {
+		t.Errorf("logged %d of 300 entries, want 102", logged)
+	}
+}
+
+func TestSamplingRejectsDropAllRules(t *testing.T) {
+	tests := []SamplingConfig{
+		{Overrides: map[string]SamplingRule{"x": {}}},
+		{SamplingRule: SamplingRule{First: -1, Thereafter: 1}},
+		{Tick: ConfigDuration(-time.Second)},
+	}
+	for _, cfg := range tests {
+		if _, err := NewLogger(WithSampling(cfg), WithDisableConsole()); err == nil {
+			t.Errorf("NewLogger(WithSampling(%+v)) succeeded", cfg)
+		}
+		c := Config{Sampling: &cfg}
+		if err := c.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded", cfg)
+		}
+	}
+}
+
+func TestSamplingReportDisabled(t *testing.T) {
+	cfg := SamplingConfig{ReportInterval: -1}.withDefaults()
+	if cfg.ReportInterval >= 0 {
+		t.Errorf("ReportInterval = %v, a negative interval must stay disabled", cfg.ReportInterval)
+	}
+}
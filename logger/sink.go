@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"go.uber.org/zap/zapcore"
+)
+
+// Sink receives every entry encoded as JSON, it is installed with WithSink.
+type Sink interface {
+	WriteEntry(ent zapcore.Entry, p []byte) error
+	Sync() error
+	Close() error
+}
+
+// sinkCore encodes entries for a Sink, unlike zapcore.NewCore it hands the entry to the sink
+// so sinks such as syslog can map the level.
+type sinkCore struct {
+	zapcore.LevelEnabler
+	enc  zapcore.Encoder
+	sink Sink
+}
+
+func newSinkCore(enc zapcore.Encoder, sink Sink, enab zapcore.LevelEnabler) zapcore.Core {
+	return &sinkCore{LevelEnabler: enab, enc: enc, sink: sink}
+}
+
+func (c *sinkCore) With(fields []zapcore.Field) zapcore.Core {
+	enc := c.enc.Clone()
+	for i := range fields {
+		fields[i].AddTo(enc)
+	}
+	return &sinkCore{LevelEnabler: c.LevelEnabler, enc: enc, sink: c.sink}
+}
+
+func (c *sinkCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *sinkCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	err = c.sink.WriteEntry(ent, buf.Bytes())
+	buf.Free()
+	if err != nil {
+		return err
+	}
+	if ent.Level > zapcore.ErrorLevel {
+		// the process may exit on panic and fatal, flush like zapcore.NewCore does
+		_ = c.sink.Sync()
+	}
+	return nil
+}
+
+func (c *sinkCore) Sync() error {
+	return c.sink.Sync()
+}
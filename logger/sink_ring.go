@@ -0,0 +1,95 @@
+package logger
+
+import (
+	"github.com/metaitself/xmeta/encoding/json"
+	"go.uber.org/zap/zapcore"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// RingSink keeps the most recent entries in memory, for debugging through its http.Handler.
+type RingSink struct {
+	mu      sync.Mutex
+	entries []ringEntry
+	next    int
+	full    bool
+}
+
+type ringEntry struct {
+	level zapcore.Level
+	data  json.RawMessage
+}
+
+var _ Sink = (*RingSink)(nil)
+
+// NewRingSink returns a sink keeping the last size entries.
+func NewRingSink(size int) *RingSink {
+	if size <= 0 {
+		size = 1000
+	}
+	return &RingSink{entries: make([]ringEntry, size)}
+}
+
+func (s *RingSink) WriteEntry(ent zapcore.Entry, p []byte) error {
+	data := make([]byte, len(p))
+	copy(data, p)
+
+	s.mu.Lock()
+	s.entries[s.next] = ringEntry{level: ent.Level, data: data}
+	s.next = (s.next + 1) % len(s.entries)
+	if s.next == 0 {
+		s.full = true
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// Entries returns the entries at or above level, oldest first.
+func (s *RingSink) Entries(level zapcore.Level) []json.RawMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ordered []ringEntry
+	if s.full {
+		ordered = append(ordered, s.entries[s.next:]...)
+	}
+	ordered = append(ordered, s.entries[:s.next]...)
+
+	out := make([]json.RawMessage, 0, len(ordered))
+	for _, e := range ordered {
+		if e.level >= level {
+			out = append(out, e.data)
+		}
+	}
+	return out
+}
+
+func (s *RingSink) Sync() error {
+	return nil
+}
+
+func (s *RingSink) Close() error {
+	return nil
+}
+
+// ServeHTTP returns the recent entries as a JSON array, "level" filters by minimum level
+// and "n" limits the result to the newest n entries.
+func (s *RingSink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	level := zapcore.DebugLevel
+	if lv := r.URL.Query().Get("level"); lv != "" {
+		var err error
+		if level, err = ParseLevel(lv); err != nil {
+			writeLevelError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	entries := s.Entries(level)
+	if n, err := strconv.Atoi(r.URL.Query().Get("n")); err == nil && n >= 0 && n < len(entries) {
+		entries = entries[len(entries)-n:]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(entries)
+}
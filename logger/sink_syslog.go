@@ -0,0 +1,150 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"go.uber.org/zap/zapcore"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SyslogFacility the syslog facility, see RFC 5424 section 6.2.1.
+type SyslogFacility int
+
+const (
+	FacilityUser   SyslogFacility = 1
+	FacilityDaemon SyslogFacility = 3
+	FacilityLocal0 SyslogFacility = 16
+	FacilityLocal1 SyslogFacility = 17
+	FacilityLocal2 SyslogFacility = 18
+	FacilityLocal3 SyslogFacility = 19
+	FacilityLocal4 SyslogFacility = 20
+	FacilityLocal5 SyslogFacility = 21
+	FacilityLocal6 SyslogFacility = 22
+	FacilityLocal7 SyslogFacility = 23
+)
+
+// SyslogSink writes RFC 5424 messages to a syslog daemon over a unix socket or UDP.
+type SyslogSink struct {
+	network  string
+	addr     string
+	facility SyslogFacility
+	appName  string
+	hostname string
+	pid      string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+var _ Sink = (*SyslogSink)(nil)
+
+// NewSyslogSink dials the syslog daemon, network is "unixgram", "unix" or "udp",
+// e.g. NewSyslogSink("unixgram", "/dev/log", FacilityLocal0, "app").
+func NewSyslogSink(network, addr string, facility SyslogFacility, appName string) (*SyslogSink, error) {
+	switch network {
+	case "unixgram", "unix", "udp", "udp4", "udp6":
+	default:
+		return nil, fmt.Errorf("logger: unsupported syslog network %q", network)
+	}
+
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+	if appName == "" {
+		appName = "-"
+	}
+
+	s := &SyslogSink{
+		network:  network,
+		addr:     addr,
+		facility: facility,
+		appName:  appName,
+		hostname: hostname,
+		pid:      strconv.Itoa(os.Getpid()),
+	}
+	if err := s.dial(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *SyslogSink) dial() error {
+	conn, err := net.DialTimeout(s.network, s.addr, 3*time.Second)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+	return nil
+}
+
+func (s *SyslogSink) WriteEntry(ent zapcore.Entry, p []byte) error {
+	msg := s.format(ent, p)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn != nil {
+		if _, err := s.conn.Write(msg); err == nil {
+			return nil
+		}
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+	// the daemon may have restarted, redial once
+	if err := s.dial(); err != nil {
+		return err
+	}
+	_, err := s.conn.Write(msg)
+	return err
+}
+
+// format builds "<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG".
+func (s *SyslogSink) format(ent zapcore.Entry, p []byte) []byte {
+	pri := int(s.facility)*8 + syslogSeverity(ent.Level)
+
+	var b bytes.Buffer
+	b.Grow(len(p) + 64)
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %s - - ",
+		pri, ent.Time.Format(time.RFC3339Nano), s.hostname, s.appName, s.pid)
+	b.Write(bytes.TrimRight(p, "\n"))
+	if s.network == "unix" {
+		// stream sockets need a frame delimiter
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
+
+func (s *SyslogSink) Sync() error {
+	return nil
+}
+
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func syslogSeverity(lvl zapcore.Level) int {
+	switch lvl {
+	case zapcore.DebugLevel:
+		return 7
+	case zapcore.InfoLevel:
+		return 6
+	case zapcore.WarnLevel:
+		return 4
+	case zapcore.ErrorLevel:
+		return 3
+	}
+	return 2
+}
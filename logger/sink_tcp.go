@@ -0,0 +1,140 @@
+package logger
+
+import (
+	"errors"
+	"go.uber.org/zap/zapcore"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrSinkClosed is returned when writing to a closed sink.
+var ErrSinkClosed = errors.New("logger: sink closed")
+
+// TCPSink writes newline-delimited JSON to a TCP endpoint, reconnecting with
+// exponential backoff. Connecting and writing happen on a background goroutine
+// through an AsyncWriteSyncer, entries written while disconnected or while the queue
+// is full are dropped and counted by Dropped.
+type TCPSink struct {
+	w     *tcpWriter
+	async *AsyncWriteSyncer
+}
+
+var _ Sink = (*TCPSink)(nil)
+
+// NewTCPSink returns a sink for addr, the connection is established on the first write.
+func NewTCPSink(addr string) *TCPSink {
+	w := &tcpWriter{
+		addr:         addr,
+		minBackoff:   100 * time.Millisecond,
+		maxBackoff:   30 * time.Second,
+		writeTimeout: 3 * time.Second,
+	}
+	return &TCPSink{w: w, async: NewAsyncWriteSyncer(w, DefaultAsyncQueueSize, false)}
+}
+
+// WithBackoff sets the reconnect backoff range.
+func (s *TCPSink) WithBackoff(min, max time.Duration) *TCPSink {
+	s.w.mu.Lock()
+	s.w.minBackoff, s.w.maxBackoff = min, max
+	s.w.mu.Unlock()
+	return s
+}
+
+func (s *TCPSink) WriteEntry(_ zapcore.Entry, p []byte) error {
+	if _, err := s.async.Write(p); err != nil {
+		return ErrSinkClosed
+	}
+	return nil
+}
+
+// Dropped returns the number of entries dropped while disconnected or because the queue was full.
+func (s *TCPSink) Dropped() uint64 {
+	return s.w.dropped.Load() + s.async.Dropped()
+}
+
+// Sync waits until the queued entries are written or dropped.
+func (s *TCPSink) Sync() error {
+	return s.async.Sync()
+}
+
+// Close flushes the queue and closes the connection.
+func (s *TCPSink) Close() error {
+	_ = s.async.Close()
+	return s.w.close()
+}
+
+// tcpWriter is the WriteSyncer behind the async queue of a TCPSink, it never returns
+// an error so zap doesn't report every entry dropped while disconnected.
+type tcpWriter struct {
+	addr         string
+	writeTimeout time.Duration
+
+	mu         sync.Mutex
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	conn       net.Conn
+	backoff    time.Duration
+	nextDial   time.Time
+	dropped    atomic.Uint64
+}
+
+func (w *tcpWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil && !w.dial() {
+		w.dropped.Add(1)
+		return len(p), nil
+	}
+
+	_ = w.conn.SetWriteDeadline(time.Now().Add(w.writeTimeout))
+	if _, err := w.conn.Write(p); err != nil {
+		_ = w.conn.Close()
+		w.conn = nil
+		w.failed()
+		w.dropped.Add(1)
+	}
+	return len(p), nil
+}
+
+// dial connects unless still backing off from the previous failure, w.mu must be held.
+func (w *tcpWriter) dial() bool {
+	if time.Now().Before(w.nextDial) {
+		return false
+	}
+	conn, err := net.DialTimeout("tcp", w.addr, w.writeTimeout)
+	if err != nil {
+		w.failed()
+		return false
+	}
+	w.conn = conn
+	w.backoff = 0
+	return true
+}
+
+func (w *tcpWriter) failed() {
+	if w.backoff == 0 {
+		w.backoff = w.minBackoff
+	} else if w.backoff *= 2; w.backoff > w.maxBackoff {
+		w.backoff = w.maxBackoff
+	}
+	w.nextDial = time.Now().Add(w.backoff)
+}
+
+func (w *tcpWriter) Sync() error {
+	return nil
+}
+
+func (w *tcpWriter) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
@@ -0,0 +1,271 @@
+package logger
+
+import (
+	"bufio"
+	"github.com/metaitself/xmeta/encoding/json"
+	"go.uber.org/zap/zapcore"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testEntry(level zapcore.Level) zapcore.Entry {
+	return zapcore.Entry{Level: level, Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), Message: "hello"}
+}
+
+// acceptLines accepts connections on ln and sends every line it reads to the returned channel.
+func acceptLines(t *testing.T, ln net.Listener) <-chan string {
+	t.Helper()
+	lines := make(chan string, 100)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				sc := bufio.NewScanner(conn)
+				for sc.Scan() {
+					lines <- sc.Text()
+				}
+			}()
+		}
+	}()
+	return lines
+}
+
+func readLine(t *testing.T, lines <-chan string) string {
+	t.Helper()
+	select {
+	case l := <-lines:
+		return l
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for a line")
+	}
+	return ""
+}
+
+func TestTCPSinkWrite(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	lines := acceptLines(t, ln)
+
+	s := NewTCPSink(ln.Addr().String())
+	defer s.Close()
+	for _, msg := range []string{`{"n":1}`, `{"n":2}`} {
+		if err = s.WriteEntry(testEntry(zapcore.InfoLevel), []byte(msg+"\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = s.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readLine(t, lines); got != `{"n":1}` {
+		t.Errorf("got %q", got)
+	}
+	if got := readLine(t, lines); got != `{"n":2}` {
+		t.Errorf("got %q", got)
+	}
+	if d := s.Dropped(); d != 0 {
+		t.Errorf("Dropped() = %d, want 0", d)
+	}
+}
+
+func TestTCPSinkBackoff(t *testing.T) {
+	// a port nothing listens on
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	s := NewTCPSink(addr).WithBackoff(time.Hour, time.Hour)
+	defer s.Close()
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		// entries dropped while disconnected aren't errors, zap would report each of them
+		if err = s.WriteEntry(testEntry(zapcore.InfoLevel), []byte("{}\n")); err != nil {
+			t.Fatalf("WriteEntry() = %v, want nil", err)
+		}
+	}
+	_ = s.Sync()
+	if d := s.Dropped(); d != 5 {
+		t.Errorf("Dropped() = %d, want 5", d)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("writes took %v, the sink must not redial while backing off", elapsed)
+	}
+
+	s.w.mu.Lock()
+	backoff, nextDial := s.w.backoff, s.w.nextDial
+	s.w.mu.Unlock()
+	if backoff != time.Hour || time.Until(nextDial) < 59*time.Minute {
+		t.Errorf("backoff = %v until %v, want one hour", backoff, nextDial)
+	}
+}
+
+func TestTCPSinkReconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	s := NewTCPSink(addr).WithBackoff(10*time.Millisecond, 20*time.Millisecond)
+	defer s.Close()
+	_ = s.WriteEntry(testEntry(zapcore.InfoLevel), []byte(`{"n":"lost"}`+"\n"))
+	_ = s.Sync()
+	if d := s.Dropped(); d != 1 {
+		t.Fatalf("Dropped() = %d, want 1", d)
+	}
+
+	if ln, err = net.Listen("tcp", addr); err != nil {
+		t.Skipf("can't listen on %s again: %v", addr, err)
+	}
+	defer ln.Close()
+	lines := acceptLines(t, ln)
+
+	// retry until the backoff expired and the sink reconnected
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		_ = s.WriteEntry(testEntry(zapcore.InfoLevel), []byte(`{"n":"again"}`+"\n"))
+		_ = s.Sync()
+		select {
+		case got := <-lines:
+			if got != `{"n":"again"}` {
+				t.Errorf("got %q", got)
+			}
+			return
+		case <-time.After(20 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the sink didn't reconnect")
+		}
+	}
+}
+
+func TestTCPSinkClosed(t *testing.T) {
+	s := NewTCPSink("127.0.0.1:1")
+	_ = s.Close()
+	if err := s.WriteEntry(testEntry(zapcore.InfoLevel), []byte("{}\n")); err != ErrSinkClosed {
+		t.Errorf("WriteEntry() = %v, want ErrSinkClosed", err)
+	}
+}
+
+var rfc5424 = regexp.MustCompile(`^<(\d+)>1 (\S+) (\S+) (\S+) (\d+) - - (\{.*\})$`)
+
+func TestSyslogSinkUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	s, err := NewSyslogSink("udp", pc.LocalAddr().String(), FacilityLocal0, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if err = s.WriteEntry(testEntry(zapcore.WarnLevel), []byte(`{"msg":"hello"}`+"\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = pc.SetReadDeadline(time.Now().Add(3 * time.Second))
+	buf := make([]byte, 2048)
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := rfc5424.FindStringSubmatch(string(buf[:n]))
+	if m == nil {
+		t.Fatalf("%q isn't an RFC 5424 message", buf[:n])
+	}
+	// local0 (16) * 8 + warning (4)
+	if m[1] != "132" {
+		t.Errorf("PRI = %s, want 132", m[1])
+	}
+	if m[2] != "2024-01-02T03:04:05Z" {
+		t.Errorf("TIMESTAMP = %s", m[2])
+	}
+	if m[4] != "app" {
+		t.Errorf("APP-NAME = %s, want app", m[4])
+	}
+	if m[6] != `{"msg":"hello"}` {
+		t.Errorf("MSG = %s", m[6])
+	}
+}
+
+func TestSyslogSinkUnixStreamFraming(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "syslog.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	defer ln.Close()
+	lines := acceptLines(t, ln)
+
+	s, err := NewSyslogSink("unix", path, FacilityUser, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for _, lvl := range []zapcore.Level{zapcore.ErrorLevel, zapcore.DebugLevel} {
+		if err = s.WriteEntry(testEntry(lvl), []byte(`{}`+"\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// each message is terminated by a newline on stream sockets
+	for _, pri := range []string{"11", "15"} {
+		m := rfc5424.FindStringSubmatch(readLine(t, lines))
+		if m == nil || m[1] != pri || m[4] != "-" {
+			t.Errorf("got %q, want PRI %s and a nil APP-NAME", m, pri)
+		}
+	}
+}
+
+func TestRingSink(t *testing.T) {
+	s := NewRingSink(2)
+	for i, lvl := range []zapcore.Level{zapcore.InfoLevel, zapcore.WarnLevel, zapcore.ErrorLevel} {
+		p := []byte(`{"n":` + string(rune('0'+i)) + "}\n")
+		_ = s.WriteEntry(testEntry(lvl), p)
+		// the encoder reuses its buffer
+		p[len(p)-2] = 'x'
+	}
+
+	got := s.Entries(zapcore.DebugLevel)
+	if len(got) != 2 || strings.TrimSpace(string(got[0])) != `{"n":1}` || strings.TrimSpace(string(got[1])) != `{"n":2}` {
+		t.Fatalf("Entries() = %s", got)
+	}
+	if got = s.Entries(zapcore.ErrorLevel); len(got) != 1 {
+		t.Errorf("Entries(error) = %s", got)
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?level=warn&n=1", nil))
+	var body []json.RawMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body) != 1 || strings.TrimSpace(string(body[0])) != `{"n":2}` {
+		t.Errorf("GET = %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?level=warning", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown level status = %d, want 400", rec.Code)
+	}
+}
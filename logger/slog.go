@@ -0,0 +1,315 @@
+package logger
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"log/slog"
+	"os"
+	"runtime"
+	"sort"
+	"sync/atomic"
+)
+
+// NewSlogHandler returns a slog.Handler writing to the cores of l, so slog records share
+// its sinks, levels and format. Groups map to zap namespaces and LogValuer values are resolved.
+func NewSlogHandler(l Logger) slog.Handler {
+	h := &slogHandler{core: zapcore.NewNopCore()}
+	if zl, ok := asZapLogger(l); ok {
+		h.core = zl.zl.Core()
+		h.name = zl.zl.Name()
+		h.metadataKeys = zl.metadataKeys
+	}
+	return h
+}
+
+// SetSlogDefault makes slog.Info and friends write to the default logger, the handler
+// follows the default logger when it is replaced by SetDefault or New.
+func SetSlogDefault() {
+	slog.SetDefault(slog.New(newDefaultSlogHandler(nil, nil)))
+}
+
+// defaultSlogHandler is a slog.Handler rebuilt from the current default logger when it
+// is replaced, like lazyLogger.
+type defaultSlogHandler struct {
+	parent *defaultSlogHandler
+	derive func(slog.Handler) slog.Handler
+
+	cache atomic.Pointer[slogHandlerCache]
+}
+
+type slogHandlerCache struct {
+	d *defaultLogger
+	h slog.Handler
+}
+
+func newDefaultSlogHandler(parent *defaultSlogHandler, derive func(slog.Handler) slog.Handler) *defaultSlogHandler {
+	return &defaultSlogHandler{parent: parent, derive: derive}
+}
+
+func (h *defaultSlogHandler) resolve() slog.Handler {
+	d := _default.Load()
+	if c := h.cache.Load(); c != nil && c.d == d {
+		return c.h
+	}
+
+	var rh slog.Handler
+	if h.parent != nil {
+		rh = h.derive(h.parent.resolve())
+	} else {
+		rh = NewSlogHandler(d.l)
+	}
+	h.cache.Store(&slogHandlerCache{d: d, h: rh})
+	return rh
+}
+
+func (h *defaultSlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.resolve().Enabled(ctx, level)
+}
+
+func (h *defaultSlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.resolve().Handle(ctx, r)
+}
+
+func (h *defaultSlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return newDefaultSlogHandler(h, func(p slog.Handler) slog.Handler { return p.WithAttrs(attrs) })
+}
+
+func (h *defaultSlogHandler) WithGroup(name string) slog.Handler {
+	return newDefaultSlogHandler(h, func(p slog.Handler) slog.Handler { return p.WithGroup(name) })
+}
+
+type slogHandler struct {
+	core zapcore.Core
+	name string
+	// groups opened by WithGroup which don't hold any attrs yet
+	groups       []string
+	metadataKeys []string
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.core.Enabled(zapLevel(level))
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	ent := zapcore.Entry{
+		Level:      zapLevel(r.Level),
+		Time:       r.Time,
+		LoggerName: h.name,
+		Message:    r.Message,
+	}
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		ent.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+	}
+
+	ce := h.core.Check(ent, nil)
+	if ce == nil {
+		return nil
+	}
+	// report write errors like a zap.Logger does instead of losing them silently
+	ce.ErrorOutput = zapcore.Lock(os.Stderr)
+
+	fields := fieldsFromContext(ctx, h.metadataKeys)
+	if r.NumAttrs() > 0 {
+		fields = append(fields, h.namespaces()...)
+		r.Attrs(func(a slog.Attr) bool {
+			fields = appendAttr(fields, a)
+			return true
+		})
+	}
+	ce.Write(fields...)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	fields := h.namespaces()
+	for _, a := range attrs {
+		fields = appendAttr(fields, a)
+	}
+
+	c := *h
+	c.core = h.core.With(fields)
+	c.groups = nil
+	return &c
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	c := *h
+	c.groups = append(h.groups[:len(h.groups):len(h.groups)], name)
+	return &c
+}
+
+func (h *slogHandler) namespaces() []Field {
+	fields := make([]Field, 0, len(h.groups))
+	for _, g := range h.groups {
+		fields = append(fields, Namespace(g))
+	}
+	return fields
+}
+
+func appendAttr(fields []Field, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return fields
+		}
+		if a.Key == "" {
+			for _, ga := range attrs {
+				fields = appendAttr(fields, ga)
+			}
+			return fields
+		}
+		return append(fields, Object(a.Key, slogGroup(attrs)))
+	case slog.KindString:
+		return append(fields, String(a.Key, a.Value.String()))
+	case slog.KindInt64:
+		return append(fields, Int64(a.Key, a.Value.Int64()))
+	case slog.KindUint64:
+		return append(fields, Uint64(a.Key, a.Value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, Float64(a.Key, a.Value.Float64()))
+	case slog.KindBool:
+		return append(fields, Bool(a.Key, a.Value.Bool()))
+	case slog.KindDuration:
+		return append(fields, Duration(a.Key, a.Value.Duration()))
+	case slog.KindTime:
+		return append(fields, Time(a.Key, a.Value.Time()))
+	}
+
+	if err, ok := a.Value.Any().(error); ok {
+		return append(fields, NamedErr(a.Key, err))
+	}
+	return append(fields, Any(a.Key, a.Value.Any()))
+}
+
+// slogGroup encodes the attrs of a slog.Group as a zap object.
+type slogGroup []slog.Attr
+
+func (g slogGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, f := range appendAttr(nil, slog.Attr{Value: slog.GroupValue(g...)}) {
+		f.AddTo(enc)
+	}
+	return nil
+}
+
+func zapLevel(l slog.Level) zapcore.Level {
+	switch {
+	case l < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case l < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case l < slog.LevelError:
+		return zapcore.WarnLevel
+	}
+	return zapcore.ErrorLevel
+}
+
+func slogLevel(l zapcore.Level) slog.Level {
+	switch l {
+	case zapcore.DebugLevel:
+		return slog.LevelDebug
+	case zapcore.InfoLevel:
+		return slog.LevelInfo
+	case zapcore.WarnLevel:
+		return slog.LevelWarn
+	}
+	return slog.LevelError + slog.Level(l-zapcore.ErrorLevel)
+}
+
+// NewSlogLogger returns a Logger writing to h, entries below the level set with SetLevel
+// are dropped before reaching h. Zap namespaces map to slog groups.
+func NewSlogLogger(h slog.Handler) Logger {
+	al := zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	core := &slogCore{LevelEnabler: al, h: h}
+	return NewFromZap(zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1)), al)
+}
+
+// slogCore is a zapcore.Core writing to a slog.Handler.
+type slogCore struct {
+	zapcore.LevelEnabler
+	h slog.Handler
+}
+
+func (c *slogCore) Enabled(lvl zapcore.Level) bool {
+	return c.LevelEnabler.Enabled(lvl) && c.h.Enabled(context.Background(), slogLevel(lvl))
+}
+
+func (c *slogCore) With(fields []zapcore.Field) zapcore.Core {
+	h := c.h
+	var attrs []slog.Attr
+	for _, f := range fields {
+		if f.Type == zapcore.NamespaceType {
+			if len(attrs) > 0 {
+				h = h.WithAttrs(attrs)
+				attrs = nil
+			}
+			h = h.WithGroup(f.Key)
+			continue
+		}
+		attrs = append(attrs, fieldAttrs([]zapcore.Field{f})...)
+	}
+	if len(attrs) > 0 {
+		h = h.WithAttrs(attrs)
+	}
+	return &slogCore{LevelEnabler: c.LevelEnabler, h: h}
+}
+
+func (c *slogCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *slogCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	r := slog.NewRecord(ent.Time, slogLevel(ent.Level), ent.Message, ent.Caller.PC)
+	if ent.LoggerName != "" {
+		r.AddAttrs(slog.String("logger", ent.LoggerName))
+	}
+	r.AddAttrs(fieldAttrs(fields)...)
+	return c.h.Handle(context.Background(), r)
+}
+
+func (c *slogCore) Sync() error {
+	return nil
+}
+
+// fieldAttrs converts zap fields to slog attrs, fields after a namespace are nested in a group.
+func fieldAttrs(fields []zapcore.Field) []slog.Attr {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return mapAttrs(enc.Fields)
+}
+
+func mapAttrs(m map[string]any) []slog.Attr {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]slog.Attr, 0, len(m))
+	for _, k := range keys {
+		if sub, ok := m[k].(map[string]any); ok {
+			attrs = append(attrs, slog.Attr{Key: k, Value: slog.GroupValue(mapAttrs(sub)...)})
+			continue
+		}
+		attrs = append(attrs, slog.Any(k, m[k]))
+	}
+	return attrs
+}
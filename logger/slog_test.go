@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewKeepsPreviousDefaultOpen(t *testing.T) {
+	prev := Default()
+	defer SetDefault(prev)
+	prevSlog := slog.Default()
+	defer slog.SetDefault(prevSlog)
+
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.log")
+	second := filepath.Join(dir, "second.log")
+
+	New(WithFileP(first), WithAsync(16, true), WithDisableConsole())
+	old := Default()
+	SetSlogDefault()
+	logger := slog.Default().With("k", "v")
+
+	New(WithFileP(second), WithDisableConsole())
+	logger.Info("slog after new")
+	old.Info("old logger after new")
+	if err := Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if err := old.(*zapLogger).Close(); err != nil {
+		t.Fatal(err)
+	}
+	defer Close()
+
+	b, err := os.ReadFile(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "slog after new") || !strings.Contains(string(b), `"k":"v"`) {
+		t.Errorf("the slog handler didn't follow the new default, got %q", b)
+	}
+	if b, _ = os.ReadFile(first); !strings.Contains(string(b), "old logger after new") {
+		t.Errorf("the replaced default was closed, got %q", b)
+	}
+}
@@ -32,7 +32,6 @@ var (
 	Complex128s = zap.Complex128s
 	Duration    = zap.Duration
 	Durations   = zap.Durations
-	Err         = zap.Error
 	Errors      = zap.Errors
 	Float32     = zap.Float32
 	Float32s    = zap.Float32s
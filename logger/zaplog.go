@@ -1,18 +1,44 @@
 package logger
 
 import (
+	"fmt"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"os"
 	"time"
 )
 
-var (
-	zl *zap.Logger
-	al zap.AtomicLevel
-)
-
+// New builds a logger from opts and installs it as the default logger. Loggers derived
+// with WithFields, Named and Ctx and the handler of SetSlogDefault switch to the new one.
+// The previous default logger isn't closed since loggers taken from Default may still
+// write to it, close it explicitly once it is no longer used:
+//
+//	prev := logger.Default()
+//	logger.New(opts...)
+//	_ = prev.(io.Closer).Close()
 func New(opts ...Option) {
+	l, err := NewLogger(opts...)
+	if err != nil {
+		panic(err)
+	}
+
+	// keep the modules and the module rules set before, e.g. through LevelHandler,
+	// the root level comes from opts
+	if pl, ok := Default().(*zapLogger); ok && pl.levels != nil {
+		l.(*zapLogger).levels.inherit(pl.levels)
+	}
+	SetDefault(l)
+}
+
+func NewDevelopment(opts ...Option) {
+	opts = append(opts, WithCaller(true))
+	opts = append(opts, WithLevel("debug"))
+	opts = append(opts, WithEncode("console"))
+	New(opts...)
+}
+
+// NewLogger returns an independent logger with its own level, it doesn't touch the default logger.
+func NewLogger(opts ...Option) (Logger, error) {
 	opt := &option{
 		level:      DefaultLevel,
 		encode:     "json",
@@ -21,6 +47,10 @@ func New(opts ...Option) {
 	for _, f := range opts {
 		f(opt)
 	}
+	if opt.err != nil {
+		opt.close()
+		return nil, opt.err
+	}
 
 	timeLayout := DefaultTimeLayout
 	if opt.timeLayout != "" {
@@ -44,6 +74,8 @@ func New(opts ...Option) {
 		EncodeDuration: zapcore.MillisDurationEncoder,
 	}
 
+	jsonConfig := encoderConfig
+
 	var encoder zapcore.Encoder
 	if opt.encode == "json" {
 		encoder = zapcore.NewJSONEncoder(encoderConfig)
@@ -52,56 +84,265 @@ func New(opts ...Option) {
 		encoder = zapcore.NewConsoleEncoder(encoderConfig)
 	}
 
-	al = zap.NewAtomicLevelAt(opt.level)
+	al := zap.NewAtomicLevelAt(opt.level)
+	levels := newLevelRegistry(al)
+
+	// the cores accept the lowest level of all modules, the root and named
+	// loggers filter with their own level through moduleCore
+	minLevel := levels.min
 
 	// lowPriority usd by info\debug\warn
 	lowPriority := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-		return lvl >= al.Level() && lvl < zapcore.ErrorLevel
+		return lvl >= minLevel.Level() && lvl < zapcore.ErrorLevel
 	})
 
 	// highPriority usd by error\panic\fatal
 	highPriority := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-		return lvl >= al.Level() && lvl >= zapcore.ErrorLevel
+		return lvl >= minLevel.Level() && lvl >= zapcore.ErrorLevel
 	})
 
-	stdout := zapcore.Lock(os.Stdout) // lock for concurrent safe
-	stderr := zapcore.Lock(os.Stderr) // lock for concurrent safe
+	var stdout, stderr zapcore.WriteSyncer
+	stdout = zapcore.Lock(os.Stdout) // lock for concurrent safe
+	stderr = zapcore.Lock(os.Stderr) // lock for concurrent safe
+	errOutput := stderr
+
+	res := newResources()
+
+	var file zapcore.WriteSyncer
+	if opt.file != nil {
+		file = zapcore.AddSync(opt.file)
+		res.files = append(res.files, opt.file)
+	}
+
+	levelFiles := make([]zapcore.WriteSyncer, len(opt.levelFiles))
+	for i, lf := range opt.levelFiles {
+		levelFiles[i] = zapcore.AddSync(lf.w)
+		res.files = append(res.files, lf.w)
+	}
+
+	if opt.async {
+		wrap := func(ws zapcore.WriteSyncer) zapcore.WriteSyncer {
+			a := NewAsyncWriteSyncer(ws, opt.asyncSize, opt.asyncBlock)
+			res.asyncs = append(res.asyncs, a)
+			return a
+		}
+		if !opt.disableConsole {
+			stdout, stderr = wrap(stdout), wrap(stderr)
+		}
+		if file != nil {
+			file = wrap(file)
+		}
+		for i := range levelFiles {
+			levelFiles[i] = wrap(levelFiles[i])
+		}
+	}
+
+	newCore := func(ws zapcore.WriteSyncer, enab zapcore.LevelEnabler) zapcore.Core {
+		c := zapcore.NewCore(encoder, ws, enab)
+		if opt.redactor != nil {
+			c = &redactCore{Core: c, r: opt.redactor}
+		}
+		return c
+	}
 
 	core := zapcore.NewTee()
 
 	if !opt.disableConsole {
 		core = zapcore.NewTee(
-			zapcore.NewCore(encoder,
-				zapcore.NewMultiWriteSyncer(stdout),
-				lowPriority,
-			),
-			zapcore.NewCore(encoder,
-				zapcore.NewMultiWriteSyncer(stderr),
-				highPriority,
-			),
+			newCore(zapcore.NewMultiWriteSyncer(stdout), lowPriority),
+			newCore(zapcore.NewMultiWriteSyncer(stderr), highPriority),
 		)
 	}
 
-	if opt.file != nil {
+	if file != nil {
+		core = zapcore.NewTee(core, newCore(file, minLevel))
+	}
+
+	for i, lf := range opt.levelFiles {
+		level := lf.level
 		core = zapcore.NewTee(core,
-			zapcore.NewCore(encoder, zapcore.AddSync(opt.file), &al),
+			newCore(levelFiles[i], zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+				return lvl >= minLevel.Level() && lvl >= level
+			})),
 		)
 	}
 
+	if len(opt.sinks) > 0 {
+		sinkEncoder := encoder
+		if opt.encode != "json" {
+			sinkEncoder = zapcore.NewJSONEncoder(jsonConfig)
+		}
+		for _, sink := range opt.sinks {
+			var c zapcore.Core = newSinkCore(sinkEncoder, sink, minLevel)
+			if opt.redactor != nil {
+				c = &redactCore{Core: c, r: opt.redactor}
+			}
+			core = zapcore.NewTee(core, c)
+			res.sinks = append(res.sinks, sink)
+		}
+	}
+
 	var zapOpt []zap.Option
-	zapOpt = append(zapOpt, zap.ErrorOutput(stderr))
+	zapOpt = append(zapOpt, zap.ErrorOutput(errOutput))
 
 	if opt.addCaller {
 		zapOpt = append(zapOpt, zap.AddCaller())
 		zapOpt = append(zapOpt, zap.AddCallerSkip(opt.callerSkip))
 	}
 
-	zl = zap.New(core, zapOpt...)
+	if opt.sampling != nil {
+		s := newSampler(*opt.sampling)
+		s.report(core, res.done)
+		core = &samplerCore{Core: core, s: s}
+	}
+
+	if opt.reopenOnSIGHUP {
+		res.watchSIGHUP()
+	}
+
+	return &zapLogger{
+		zl:           zap.New(&moduleCore{Core: core, level: al}, zapOpt...),
+		al:           al,
+		levels:       levels,
+		res:          res,
+		metadataKeys: opt.metadataKeys,
+	}, nil
 }
 
-func NewDevelopment(opts ...Option) {
-	opts = append(opts, WithCaller(true))
-	opts = append(opts, WithLevel("debug"))
-	opts = append(opts, WithEncode("console"))
-	New(opts...)
+// NewFromZap wraps an existing zap logger, level changes apply to al.
+func NewFromZap(z *zap.Logger, al zap.AtomicLevel) Logger {
+	return &zapLogger{zl: z, al: al, res: newResources()}
+}
+
+// zapLogger is the Logger implementation backed by zap.
+type zapLogger struct {
+	zl *zap.Logger
+	al zap.AtomicLevel
+
+	// name the full name of a named sub-logger, empty for the root logger
+	name   string
+	levels *levelRegistry
+
+	res *resources
+
+	// metadataKeys the metadata keys added to entries logged with a context
+	metadataKeys []string
+}
+
+func (l *zapLogger) clone(z *zap.Logger) *zapLogger {
+	c := *l
+	c.zl = z
+	return &c
+}
+
+// Zap returns the underlying zap logger.
+func (l *zapLogger) Zap() *zap.Logger {
+	return l.zl
+}
+
+func (l *zapLogger) With(fields ...Field) Logger {
+	return l.clone(l.zl.With(fields...))
+}
+
+// Named returns a sub-logger whose level can be set independently, see SetModuleLevel.
+func (l *zapLogger) Named(name string) Logger {
+	full := name
+	if l.name != "" {
+		full = l.name + "." + name
+	}
+
+	z := l.zl.Named(name)
+	if l.levels != nil {
+		lvl := l.levels.module(full)
+		z = z.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+			if mc, ok := c.(*moduleCore); ok {
+				c = mc.Core
+			}
+			return &moduleCore{Core: c, level: lvl}
+		}))
+	}
+
+	c := l.clone(z)
+	c.name = full
+	return c
+}
+
+// SetLevel changes the root level, or the level of the module for a named sub-logger.
+func (l *zapLogger) SetLevel(lv string) {
+	if l.levels == nil {
+		l.al.SetLevel(getLoggerLevel(lv))
+		return
+	}
+	if l.name == "" {
+		l.levels.setRoot(getLoggerLevel(lv))
+		return
+	}
+	_ = l.levels.set(l.name, getLoggerLevel(lv).String())
+}
+
+func (l *zapLogger) Sync() error {
+	return l.zl.Sync()
+}
+
+// Close flushes buffered log entries, stops the async writers and closes the log files.
+func (l *zapLogger) Close() error {
+	err := l.zl.Sync()
+	if cerr := l.res.close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Reopen reopens the log files, e.g. after an external logrotate.
+func (l *zapLogger) Reopen() error {
+	return l.res.reopen()
+}
+
+// Dropped returns the number of entries dropped by the async writers because their queue was full.
+func (l *zapLogger) Dropped() uint64 {
+	var n uint64
+	for _, a := range l.res.asyncs {
+		n += a.Dropped()
+	}
+	return n
+}
+
+func (l *zapLogger) Debug(msg string, fields ...Field) {
+	l.zl.Debug(msg, fields...)
+}
+
+func (l *zapLogger) Info(msg string, fields ...Field) {
+	l.zl.Info(msg, fields...)
+}
+
+func (l *zapLogger) Warn(msg string, fields ...Field) {
+	l.zl.Warn(msg, fields...)
+}
+
+func (l *zapLogger) Error(msg string, fields ...Field) {
+	l.zl.Error(msg, fields...)
+}
+
+func (l *zapLogger) Fatal(msg string, fields ...Field) {
+	l.zl.Fatal(msg, fields...)
+}
+
+func (l *zapLogger) Debugf(format string, args ...any) {
+	l.zl.Debug(fmt.Sprintf(format, args...))
+}
+
+func (l *zapLogger) Infof(format string, args ...any) {
+	l.zl.Info(fmt.Sprintf(format, args...))
+}
+
+func (l *zapLogger) Warnf(format string, args ...any) {
+	l.zl.Warn(fmt.Sprintf(format, args...))
+}
+
+func (l *zapLogger) Errorf(format string, args ...any) {
+	l.zl.Error(fmt.Sprintf(format, args...))
+}
+
+func (l *zapLogger) Fatalf(format string, args ...any) {
+	l.zl.Fatal(fmt.Sprintf(format, args...))
 }
@@ -0,0 +1,204 @@
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// BaggageHeader the header carrying W3C Baggage.
+const BaggageHeader = "baggage"
+
+// The limits of the W3C Baggage specification.
+const (
+	MaxBaggageMembers = 64
+	MaxBaggageBytes   = 8192
+)
+
+var (
+	ErrBaggageTooLarge = errors.New("metadata: baggage exceeds the size limits")
+	ErrInvalidBaggage  = errors.New("metadata: invalid baggage")
+)
+
+// BaggageProperty a property of a baggage member, e.g. "ttl=60" or a bare "secret".
+type BaggageProperty struct {
+	Key      string
+	Value    string
+	HasValue bool
+}
+
+// BaggageMember one key-value pair of the baggage with its properties.
+type BaggageMember struct {
+	Key        string
+	Value      string
+	Properties []BaggageProperty
+}
+
+// Baggage the list members of a W3C Baggage header, see https://www.w3.org/TR/baggage/.
+type Baggage []BaggageMember
+
+// ParseBaggage parses a W3C Baggage header value, values are percent-decoded.
+func ParseBaggage(s string) (Baggage, error) {
+	if len(s) > MaxBaggageBytes {
+		return nil, ErrBaggageTooLarge
+	}
+
+	var b Baggage
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		m, err := parseBaggageMember(item)
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, m)
+	}
+	if len(b) > MaxBaggageMembers {
+		return nil, ErrBaggageTooLarge
+	}
+	return b, nil
+}
+
+func parseBaggageMember(s string) (BaggageMember, error) {
+	var m BaggageMember
+	parts := strings.Split(s, ";")
+
+	k, v, ok := strings.Cut(parts[0], "=")
+	if !ok {
+		return m, fmt.Errorf("%w: member %q has no value", ErrInvalidBaggage, parts[0])
+	}
+	if m.Key = strings.TrimSpace(k); !isToken(m.Key) {
+		return m, fmt.Errorf("%w: invalid key %q", ErrInvalidBaggage, m.Key)
+	}
+	var err error
+	if m.Value, err = url.PathUnescape(strings.TrimSpace(v)); err != nil {
+		return m, fmt.Errorf("%w: key %q: %v", ErrInvalidBaggage, m.Key, err)
+	}
+
+	for _, p := range parts[1:] {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		var prop BaggageProperty
+		pk, pv, ok := strings.Cut(p, "=")
+		if prop.Key = strings.TrimSpace(pk); !isToken(prop.Key) {
+			return m, fmt.Errorf("%w: invalid property key %q", ErrInvalidBaggage, prop.Key)
+		}
+		if ok {
+			prop.HasValue = true
+			if prop.Value, err = url.PathUnescape(strings.TrimSpace(pv)); err != nil {
+				return m, fmt.Errorf("%w: property %q: %v", ErrInvalidBaggage, prop.Key, err)
+			}
+		}
+		m.Properties = append(m.Properties, prop)
+	}
+	return m, nil
+}
+
+// Encode returns the header value, values are percent-encoded.
+func (b Baggage) Encode() (string, error) {
+	if len(b) > MaxBaggageMembers {
+		return "", ErrBaggageTooLarge
+	}
+
+	var sb strings.Builder
+	for i, m := range b {
+		if !isToken(m.Key) {
+			return "", fmt.Errorf("%w: invalid key %q", ErrInvalidBaggage, m.Key)
+		}
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(m.Key)
+		sb.WriteByte('=')
+		sb.WriteString(escapeBaggage(m.Value))
+		for _, p := range m.Properties {
+			if !isToken(p.Key) {
+				return "", fmt.Errorf("%w: invalid property key %q", ErrInvalidBaggage, p.Key)
+			}
+			sb.WriteByte(';')
+			sb.WriteString(p.Key)
+			if p.HasValue {
+				sb.WriteByte('=')
+				sb.WriteString(escapeBaggage(p.Value))
+			}
+		}
+	}
+	if sb.Len() > MaxBaggageBytes {
+		return "", ErrBaggageTooLarge
+	}
+	return sb.String(), nil
+}
+
+// Metadata returns the members as metadata, properties are dropped and invalid keys are skipped.
+func (b Baggage) Metadata() Metadata {
+	md := make(Metadata, len(b))
+	for _, m := range b {
+		md.Add(m.Key, m.Value)
+	}
+	return md
+}
+
+// FromBaggage creates md from a W3C Baggage header value.
+func FromBaggage(s string) (Metadata, error) {
+	b, err := ParseBaggage(s)
+	if err != nil {
+		return nil, err
+	}
+	return b.Metadata(), nil
+}
+
+// ToBaggage encodes md as a W3C Baggage header value with the keys sorted, a key with
+// several values becomes several members. See ToHTTPHeader for the value conversion.
+func (m Metadata) ToBaggage() (string, error) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b := make(Baggage, 0, len(keys))
+	for _, k := range keys {
+		for _, s := range stringValues(values(m[k])) {
+			b = append(b, BaggageMember{Key: NormalizeKey(k), Value: s})
+		}
+	}
+	return b.Encode()
+}
+
+// isToken reports whether s is an RFC 7230 token.
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// escapeBaggage percent-encodes the bytes which aren't baggage-octets, and '%'.
+func escapeBaggage(s string) string {
+	const hex = "0123456789ABCDEF"
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c > 0x20 && c < 0x7f && c != '"' && c != ',' && c != ';' && c != '\\' && c != '%' {
+			sb.WriteByte(c)
+			continue
+		}
+		sb.WriteByte('%')
+		sb.WriteByte(hex[c>>4])
+		sb.WriteByte(hex[c&0xf])
+	}
+	return sb.String()
+}
@@ -0,0 +1,147 @@
+package metadata
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBaggageRoundTrip(t *testing.T) {
+	b := Baggage{
+		{Key: "user-id", Value: "42"},
+		{Key: "name", Value: "Zoë, 100% \"ok\"; a\\b=c"},
+		{Key: "route", Value: "a/b", Properties: []BaggageProperty{
+			{Key: "ttl", Value: "60", HasValue: true},
+			{Key: "secret"},
+			{Key: "note", Value: "x;y", HasValue: true},
+		}},
+	}
+	s, err := b.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the delimiters of the values are escaped, only the members and properties remain
+	if strings.ContainsAny(s, " \"\\") || strings.Count(s, ",") != 2 || strings.Count(s, ";") != 3 {
+		t.Fatalf("unescaped delimiters in %q", s)
+	}
+
+	got, err := ParseBaggage(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, b) {
+		t.Fatalf("round trip mismatch\n got: %#v\nwant: %#v\nheader: %s", got, b, s)
+	}
+}
+
+func TestParseBaggage(t *testing.T) {
+	got, err := ParseBaggage(" a = 1 ; p ; q = v%20w , ,b=%E2%9C%93")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Baggage{
+		{Key: "a", Value: "1", Properties: []BaggageProperty{{Key: "p"}, {Key: "q", Value: "v w", HasValue: true}}},
+		{Key: "b", Value: "✓"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseBaggage = %#v, want %#v", got, want)
+	}
+
+	for _, s := range []string{"novalue", "bad key=1", "a=%zz", "a=1;bad key=2", "a=1;p=%"} {
+		if _, err := ParseBaggage(s); !errors.Is(err, ErrInvalidBaggage) {
+			t.Errorf("ParseBaggage(%q) = %v, want ErrInvalidBaggage", s, err)
+		}
+	}
+}
+
+func TestBaggageLimits(t *testing.T) {
+	members := make([]string, MaxBaggageMembers+1)
+	for i := range members {
+		members[i] = "k=v"
+	}
+	if _, err := ParseBaggage(strings.Join(members, ",")); !errors.Is(err, ErrBaggageTooLarge) {
+		t.Errorf("too many members = %v, want ErrBaggageTooLarge", err)
+	}
+	if _, err := ParseBaggage(strings.Join(members[:MaxBaggageMembers], ",")); err != nil {
+		t.Errorf("the maximum members = %v", err)
+	}
+	if _, err := ParseBaggage("k=" + strings.Repeat("v", MaxBaggageBytes)); !errors.Is(err, ErrBaggageTooLarge) {
+		t.Errorf("too many bytes = %v, want ErrBaggageTooLarge", err)
+	}
+
+	if _, err := (Baggage{{Key: "k", Value: strings.Repeat("v", MaxBaggageBytes)}}).Encode(); !errors.Is(err, ErrBaggageTooLarge) {
+		t.Errorf("Encode too many bytes = %v, want ErrBaggageTooLarge", err)
+	}
+	// escaping counts towards the limit
+	if _, err := (Baggage{{Key: "k", Value: strings.Repeat(",", MaxBaggageBytes/3+1)}}).Encode(); !errors.Is(err, ErrBaggageTooLarge) {
+		t.Errorf("Encode escaped bytes = %v, want ErrBaggageTooLarge", err)
+	}
+	if _, err := (Baggage{{Key: "bad key", Value: "v"}}).Encode(); !errors.Is(err, ErrInvalidBaggage) {
+		t.Errorf("Encode invalid key = %v, want ErrInvalidBaggage", err)
+	}
+}
+
+func TestMetadataBaggage(t *testing.T) {
+	md := Metadata{"user-id": 42, "name": "a b"}
+	md.Add("tag", "x")
+	md.Add("tag", "y")
+
+	s, err := md.ToBaggage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "name=a%20b,tag=x,tag=y,user-id=42"; s != want {
+		t.Fatalf("ToBaggage = %q, want %q", s, want)
+	}
+
+	got, err := FromBaggage(s + ";ttl=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Metadata{"user-id": "42", "name": "a b", "tag": multiValue{"x", "y"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("FromBaggage = %#v, want %#v", got, want)
+	}
+}
+
+func TestMetadataBinaryRoundTrip(t *testing.T) {
+	md := Metadata{"user-id": "42", "empty": "", "bin": "\x00\xff,;="}
+	md.Add("tag", "x")
+	md.Add("tag", "y")
+
+	b, err := md.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Metadata
+	if err = got.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, md) {
+		t.Fatalf("round trip mismatch\n got: %#v\nwant: %#v", got, md)
+	}
+
+	again, _ := md.Clone().MarshalBinary()
+	if string(again) != string(b) {
+		t.Fatal("the binary encoding isn't stable")
+	}
+}
+
+func TestMetadataUnmarshalBinaryInvalid(t *testing.T) {
+	valid, _ := Metadata{"k": "value"}.MarshalBinary()
+	tests := map[string][]byte{
+		"empty":       nil,
+		"version":     append([]byte{binaryVersion + 1}, valid[1:]...),
+		"truncated":   valid[:len(valid)-1],
+		"missing":     valid[:3],
+		"bad varint":  {binaryVersion, 0xff},
+		"long length": {binaryVersion, 0x7f, 'k'},
+	}
+	for name, data := range tests {
+		var md Metadata
+		if err := md.UnmarshalBinary(data); !errors.Is(err, ErrInvalidBinary) {
+			t.Errorf("%s: UnmarshalBinary = %v, want ErrInvalidBinary", name, err)
+		}
+	}
+}
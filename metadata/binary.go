@@ -0,0 +1,69 @@
+package metadata
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+)
+
+// BinaryHeader the gRPC binary header carrying metadata encoded with MarshalBinary.
+const BinaryHeader = "xmeta-bin"
+
+const binaryVersion = 1
+
+var ErrInvalidBinary = errors.New("metadata: invalid binary encoding")
+
+// MarshalBinary encodes md as a version byte followed by length-prefixed key-value pairs,
+// a key with several values is written once per value. See ToHTTPHeader for the value conversion.
+func (m Metadata) MarshalBinary() ([]byte, error) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b := []byte{binaryVersion}
+	for _, k := range keys {
+		nk := NormalizeKey(k)
+		for _, s := range stringValues(values(m[k])) {
+			b = binary.AppendUvarint(b, uint64(len(nk)))
+			b = append(b, nk...)
+			b = binary.AppendUvarint(b, uint64(len(s)))
+			b = append(b, s...)
+		}
+	}
+	return b, nil
+}
+
+// UnmarshalBinary decodes data encoded with MarshalBinary, the pairs are added to m.
+func (m *Metadata) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || data[0] != binaryVersion {
+		return ErrInvalidBinary
+	}
+	if *m == nil {
+		*m = Metadata{}
+	}
+
+	data = data[1:]
+	next := func() (string, bool) {
+		n, l := binary.Uvarint(data)
+		if l <= 0 || n > uint64(len(data)-l) {
+			return "", false
+		}
+		s := string(data[l : l+int(n)])
+		data = data[l+int(n):]
+		return s, true
+	}
+	for len(data) > 0 {
+		k, ok := next()
+		if !ok {
+			return ErrInvalidBinary
+		}
+		v, ok := next()
+		if !ok {
+			return ErrInvalidBinary
+		}
+		m.Add(k, v)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package metadata
+
+import (
+	"fmt"
+	"github.com/metaitself/xmeta/conv"
+	"github.com/metaitself/xmeta/metaerror"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ReasonInvalidMetadata is the reason of the error returned by Bind.
+const ReasonInvalidMetadata = "INVALID_METADATA"
+
+type fieldTag struct {
+	key        string
+	def        string
+	hasDefault bool
+	required   bool
+	omitempty  bool
+}
+
+// parseFieldTag parses a tag like `md:"user-id,default=0,required"`.
+func parseFieldTag(tag string) fieldTag {
+	parts := strings.Split(tag, ",")
+	ft := fieldTag{key: NormalizeKey(parts[0])}
+	for _, p := range parts[1:] {
+		switch p = strings.TrimSpace(p); {
+		case p == "required":
+			ft.required = true
+		case p == "omitempty":
+			ft.omitempty = true
+		case strings.HasPrefix(p, "default="):
+			ft.def, ft.hasDefault = strings.TrimPrefix(p, "default="), true
+		}
+	}
+	return ft
+}
+
+// Bind fills the struct pointed to by dst from md with the `md` tags of its fields:
+//
+//	type Caller struct {
+//		UserID  int64         `md:"user-id,required"`
+//		Timeout time.Duration `md:"timeout,default=3s"`
+//	}
+//
+// Values are converted with conv, untagged fields are left as is and embedded structs
+// are bound too. Missing required keys and values which can't be converted are reported
+// together in a BadRequest MetaError.
+func Bind(md Metadata, dst any) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("metadata: Bind requires a non-nil struct pointer, got %T", dst)
+	}
+
+	problems := make(map[string]string)
+	bindStruct(md, rv.Elem(), problems)
+	if len(problems) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(problems))
+	for k := range problems {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	msgs := make([]string, len(keys))
+	for i, k := range keys {
+		msgs[i] = k + ": " + problems[k]
+	}
+	return metaerror.BadRequest(http.StatusBadRequest, ReasonInvalidMetadata,
+		"invalid metadata: "+strings.Join(msgs, "; ")).WithMetadata(problems)
+}
+
+func bindStruct(md Metadata, rv reflect.Value, problems map[string]string) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		tag, ok := sf.Tag.Lookup("md")
+		if !ok {
+			if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+				bindStruct(md, rv.Field(i), problems)
+			}
+			continue
+		}
+		if tag == "-" || !sf.IsExported() {
+			continue
+		}
+
+		ft := parseFieldTag(tag)
+		var v any
+		switch {
+		case md.IsExists(ft.key):
+			v = lookup(md, ft.key, sf.Type)
+		case ft.required:
+			problems[ft.key] = "required"
+			continue
+		case ft.hasDefault:
+			v = ft.def
+		default:
+			continue
+		}
+
+		cv, err := conv.ToTypeE(normalize(v, sf.Type), sf.Type)
+		if err != nil {
+			problems[ft.key] = err.Error()
+			continue
+		}
+		rv.Field(i).Set(cv)
+	}
+}
+
+// FromStruct creates md from the `md` tagged fields of the struct v or of the struct it
+// points to, the reverse of Bind. Slice fields become multi-value keys, nil pointers are
+// skipped and zero values too with the omitempty option.
+func FromStruct(v any) (Metadata, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("metadata: FromStruct requires a struct, got %T", v)
+	}
+
+	md := Metadata{}
+	fromStruct(md, rv)
+	return md, nil
+}
+
+func fromStruct(md Metadata, rv reflect.Value) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		fv := rv.Field(i)
+		tag, ok := sf.Tag.Lookup("md")
+		if !ok {
+			if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+				fromStruct(md, fv)
+			}
+			continue
+		}
+		if tag == "-" || !sf.IsExported() {
+			continue
+		}
+
+		ft := parseFieldTag(tag)
+		if ft.omitempty && fv.IsZero() {
+			continue
+		}
+		if fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 {
+			for j := 0; j < fv.Len(); j++ {
+				md.Add(ft.key, fv.Index(j).Interface())
+			}
+			continue
+		}
+		md.Set(ft.key, fv.Interface())
+	}
+}
@@ -0,0 +1,68 @@
+package metadata
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// normalize applies the metadata conversion rules before converting v to t with conv:
+// bare numbers are seconds for a time.Duration and integer strings are base 10, so
+// "010" reads as 10. Get, the Get* helpers and Bind all read through it.
+func normalize(v any, t reflect.Type) any {
+	if v == nil || reflect.TypeOf(v) == t {
+		return v
+	}
+
+	if t == durationType {
+		return normalizeDuration(v)
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if s, ok := v.(string); ok {
+			if n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64); err == nil {
+				return n
+			}
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if s, ok := v.(string); ok {
+			if n, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64); err == nil {
+				return n
+			}
+		}
+	case reflect.Slice:
+		if vs, ok := v.([]any); ok && t.Elem().Kind() != reflect.Uint8 {
+			out := make([]any, len(vs))
+			for i := range vs {
+				out[i] = normalize(vs[i], t.Elem())
+			}
+			return out
+		}
+	}
+	return v
+}
+
+func normalizeDuration(v any) any {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return time.Duration(rv.Int()) * time.Second
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return time.Duration(rv.Uint()) * time.Second
+	case reflect.Float32, reflect.Float64:
+		return time.Duration(rv.Float() * float64(time.Second))
+	case reflect.String:
+		s := strings.TrimSpace(rv.String())
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return time.Duration(n) * time.Second
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return time.Duration(f * float64(time.Second))
+		}
+	}
+	return v
+}
@@ -0,0 +1,48 @@
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"github.com/metaitself/xmeta/conv"
+	"reflect"
+)
+
+// ErrNotFound is returned by Get when the key doesn't exist.
+var ErrNotFound = errors.New("metadata: key not found")
+
+// Get converts the value of key to T with conv.ToAnyE. For a slice T every value of
+// a multi-value key is converted, otherwise the first value is. Integer strings are
+// base 10 and bare numbers are seconds for a time.Duration.
+func Get[T any](md Metadata, key string) (T, error) {
+	var t T
+	if md == nil || !md.IsExists(key) {
+		return t, ErrNotFound
+	}
+
+	rt := reflect.TypeOf(&t).Elem()
+	t, err := conv.ToAnyE[T](normalize(lookup(md, key, rt), rt))
+	if err != nil {
+		return t, fmt.Errorf("metadata: key %q: %w", NormalizeKey(key), err)
+	}
+	return t, nil
+}
+
+// GetOr returns the value of key converted to T, or def if the key is missing or
+// can't be converted.
+func GetOr[T any](md Metadata, key string, def T) T {
+	v, err := Get[T](md, key)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// lookup returns the value of key to convert to t, every value of a multi-value key for a slice.
+func lookup(md Metadata, key string, t reflect.Type) any {
+	if t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 {
+		if vs := md.Values(key); len(vs) > 1 {
+			return vs
+		}
+	}
+	return md.Get(key)
+}
@@ -0,0 +1,74 @@
+package metadata
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetDurationRules(t *testing.T) {
+	type caller struct {
+		Timeout time.Duration `md:"timeout"`
+	}
+
+	tests := []struct {
+		value any
+		want  time.Duration
+	}{
+		{5, 5 * time.Second},
+		{int64(5), 5 * time.Second},
+		{uint8(5), 5 * time.Second},
+		{"5", 5 * time.Second},
+		{" 5 ", 5 * time.Second},
+		{"1.5", 1500 * time.Millisecond},
+		{1.5, 1500 * time.Millisecond},
+		{"1.5s", 1500 * time.Millisecond},
+		{"250ms", 250 * time.Millisecond},
+		{3 * time.Millisecond, 3 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		md := New(map[string]any{"timeout": tt.value})
+
+		if got := GetDuration(md, "timeout"); got != tt.want {
+			t.Errorf("GetDuration(%#v) = %v, want %v", tt.value, got, tt.want)
+		}
+		if got, err := Get[time.Duration](md, "timeout"); err != nil || got != tt.want {
+			t.Errorf("Get[time.Duration](%#v) = %v, %v, want %v", tt.value, got, err, tt.want)
+		}
+		var c caller
+		if err := Bind(md, &c); err != nil || c.Timeout != tt.want {
+			t.Errorf("Bind(%#v) = %v, %v, want %v", tt.value, c.Timeout, err, tt.want)
+		}
+	}
+}
+
+func TestGetIntBase10(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"10", 10},
+		{"010", 10},
+		{"08", 8},
+		{"-7", -7},
+		{"1.0", 1},
+	}
+	for _, tt := range tests {
+		md := New(map[string]any{"n": tt.value})
+		if got := GetInt(md, "n"); got != tt.want {
+			t.Errorf("GetInt(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+		if got, err := Get[uint16](md, "n"); tt.want >= 0 && (err != nil || int(got) != tt.want) {
+			t.Errorf("Get[uint16](%q) = %d, %v, want %d", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestGetSliceElements(t *testing.T) {
+	md := Metadata{}
+	md.Add("retry", "010")
+	md.Add("retry", "2")
+	got, err := Get[[]int](md, "retry")
+	if err != nil || len(got) != 2 || got[0] != 10 || got[1] != 2 {
+		t.Errorf("Get[[]int] = %v, %v, want [10 2]", got, err)
+	}
+}
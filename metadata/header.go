@@ -0,0 +1,70 @@
+package metadata
+
+import (
+	"github.com/metaitself/xmeta/conv"
+	grpcmd "google.golang.org/grpc/metadata"
+	"net/http"
+)
+
+// FromHTTPHeader creates md from h keeping every value, invalid keys are skipped.
+func FromHTTPHeader(h http.Header) Metadata {
+	md := make(Metadata, len(h))
+	for k, vs := range h {
+		for _, v := range vs {
+			md.Add(k, v)
+		}
+	}
+	return md
+}
+
+// ToHTTPHeader converts md to an http.Header, values are converted with conv.ToStringE
+// and values which can't be converted are skipped.
+func (m Metadata) ToHTTPHeader() http.Header {
+	h := make(http.Header, len(m))
+	m.RangeValues(func(k string, vs []any) bool {
+		for _, s := range stringValues(vs) {
+			h.Add(k, s)
+		}
+		return true
+	})
+	return h
+}
+
+// FromGRPC creates md from gRPC metadata keeping every value.
+func FromGRPC(gmd grpcmd.MD) Metadata {
+	md := make(Metadata, len(gmd))
+	for k, vs := range gmd {
+		for _, v := range vs {
+			md.Add(k, v)
+		}
+	}
+	return md
+}
+
+// ToGRPC converts md to gRPC metadata, see ToHTTPHeader for the value conversion.
+func (m Metadata) ToGRPC() grpcmd.MD {
+	gmd := make(grpcmd.MD, len(m))
+	m.RangeValues(func(k string, vs []any) bool {
+		gmd.Append(k, stringValues(vs)...)
+		return true
+	})
+	return gmd
+}
+
+func stringValues(vs []any) []string {
+	ss := make([]string, 0, len(vs))
+	for _, v := range vs {
+		switch vv := v.(type) {
+		case []string:
+			ss = append(ss, vv...)
+			continue
+		case []any:
+			ss = append(ss, stringValues(vv)...)
+			continue
+		}
+		if s, err := conv.ToStringE(v); err == nil {
+			ss = append(ss, s)
+		}
+	}
+	return ss
+}
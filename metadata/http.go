@@ -0,0 +1,150 @@
+package metadata
+
+import (
+	"net/http"
+	"strings"
+)
+
+// HTTPOption custom setup the metadata propagation of HTTPMiddleware and NewTransport
+type HTTPOption func(*httpOption)
+
+type httpOption struct {
+	allow  []string
+	prefix string
+}
+
+// WithAllowedKeys the metadata keys propagated, an entry ending with '*' matches by
+// prefix. Without it nothing is propagated, credential and hop-by-hop headers never are.
+func WithAllowedKeys(keys ...string) HTTPOption {
+	return func(opt *httpOption) {
+		for _, k := range keys {
+			opt.allow = append(opt.allow, NormalizeKey(k))
+		}
+	}
+}
+
+// WithHeaderPrefix maps the key "user-id" to the header "X-Meta-User-Id" for the prefix
+// "X-Meta-", the middleware only reads headers with the prefix and strips it.
+func WithHeaderPrefix(prefix string) HTTPOption {
+	return func(opt *httpOption) {
+		opt.prefix = strings.ToLower(prefix)
+	}
+}
+
+func newHTTPOption(opts []HTTPOption) *httpOption {
+	opt := &httpOption{}
+	for _, f := range opts {
+		f(opt)
+	}
+	return opt
+}
+
+// deniedHeaders the credential and hop-by-hop headers which are never propagated.
+var deniedHeaders = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"proxy-authenticate":  true,
+	"www-authenticate":    true,
+	"cookie":              true,
+	"set-cookie":          true,
+	"connection":          true,
+	"keep-alive":          true,
+	"proxy-connection":    true,
+	"te":                  true,
+	"trailer":             true,
+	"transfer-encoding":   true,
+	"upgrade":             true,
+	"host":                true,
+	"content-length":      true,
+}
+
+func (o *httpOption) allowed(key string) bool {
+	if deniedHeaders[key] || deniedHeaders[o.prefix+key] {
+		return false
+	}
+	for _, a := range o.allow {
+		if strings.HasSuffix(a, "*") {
+			if strings.HasPrefix(key, strings.TrimSuffix(a, "*")) {
+				return true
+			}
+		} else if a == key {
+			return true
+		}
+	}
+	return false
+}
+
+// fromHeader builds the metadata of the allowed headers, see WithHeaderPrefix.
+func (o *httpOption) fromHeader(h http.Header) Metadata {
+	md := Metadata{}
+	for name, vs := range h {
+		key := strings.ToLower(name)
+		if o.prefix != "" {
+			if !strings.HasPrefix(key, o.prefix) {
+				continue
+			}
+			key = key[len(o.prefix):]
+		}
+		if key = NormalizeKey(key); !o.allowed(key) {
+			continue
+		}
+		for _, v := range vs {
+			md.Add(key, v)
+		}
+	}
+	return md
+}
+
+// HTTPMiddleware merges the metadata built from the allowed request headers into the
+// metadata of the request context, keys already set by an outer middleware are kept
+// so a client can't override them with a header.
+func HTTPMiddleware(next http.Handler, opts ...HTTPOption) http.Handler {
+	opt := newHTTPOption(opts)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		md := opt.fromHeader(r.Header)
+		if len(md) > 0 {
+			r = r.WithContext(MergeContext(r.Context(), md, false))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// NewTransport returns a RoundTripper writing the allowed metadata of the request
+// context to the outgoing headers, base defaults to http.DefaultTransport.
+func NewTransport(base http.RoundTripper, opts ...HTTPOption) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base, opt: newHTTPOption(opts)}
+}
+
+type transport struct {
+	base http.RoundTripper
+	opt  *httpOption
+}
+
+func (t *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	view, ok := ViewFromContext(r.Context())
+	if !ok || view.Len() == 0 {
+		return t.base.RoundTrip(r)
+	}
+
+	// a RoundTripper must not modify the request
+	r2 := *r
+	r2.Header = r.Header.Clone()
+	if r2.Header == nil {
+		r2.Header = make(http.Header)
+	}
+	view.RangeValues(func(k string, vs []any) bool {
+		if !t.opt.allowed(k) {
+			return true
+		}
+		name := t.opt.prefix + k
+		r2.Header.Del(name)
+		for _, s := range stringValues(vs) {
+			r2.Header.Add(name, s)
+		}
+		return true
+	})
+	return t.base.RoundTrip(&r2)
+}
@@ -0,0 +1,135 @@
+package metadata
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func serveMiddleware(t *testing.T, ctx context.Context, h http.Header, opts ...HTTPOption) View {
+	t.Helper()
+	var view View
+	handler := HTTPMiddleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		view, _ = ViewFromContext(r.Context())
+	}), opts...)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	r.Header = h
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	return view
+}
+
+func TestHTTPMiddlewareAllowedKeys(t *testing.T) {
+	h := http.Header{}
+	h.Set("X-Request-Id", "r1")
+	h.Add("X-Tenant-Region", "eu")
+	h.Add("X-Tenant-Region", "us")
+	h.Set("X-Other", "no")
+	h.Set("Authorization", "Bearer secret")
+	h.Set("Cookie", "a=b")
+
+	view := serveMiddleware(t, context.Background(), h, WithAllowedKeys("x-request-id", "x-tenant-*", "authorization", "cookie"))
+	if got := view.Get("x-request-id"); got != "r1" {
+		t.Errorf("x-request-id = %v", got)
+	}
+	if got := stringValues(view.Values("x-tenant-region")); !slices.Equal(got, []string{"eu", "us"}) {
+		t.Errorf("x-tenant-region = %v", got)
+	}
+	for _, k := range []string{"x-other", "authorization", "cookie"} {
+		if view.IsExists(k) {
+			t.Errorf("%s was propagated", k)
+		}
+	}
+}
+
+func TestHTTPMiddlewareNothingAllowed(t *testing.T) {
+	h := http.Header{}
+	h.Set("X-Request-Id", "r1")
+	if view := serveMiddleware(t, context.Background(), h); view.Len() != 0 {
+		t.Errorf("metadata = %v, want none without allowed keys", view.Metadata())
+	}
+}
+
+func TestHTTPMiddlewarePrefix(t *testing.T) {
+	h := http.Header{}
+	h.Set("X-Meta-User-Id", "u1")
+	h.Set("User-Id", "spoofed")
+	view := serveMiddleware(t, context.Background(), h, WithHeaderPrefix("X-Meta-"), WithAllowedKeys("user-id"))
+	if got := view.Get("user-id"); got != "u1" {
+		t.Errorf("user-id = %v, want u1", got)
+	}
+}
+
+func TestHTTPMiddlewareKeepsContextMetadata(t *testing.T) {
+	ctx := NewContext(context.Background(), Metadata{"user-id": "u1", "trace-id": "t1"})
+	h := http.Header{}
+	h.Set("User-Id", "spoofed")
+	h.Set("X-Request-Id", "r1")
+
+	view := serveMiddleware(t, ctx, h, WithAllowedKeys("user-id", "x-request-id"))
+	if got := view.Get("user-id"); got != "u1" {
+		t.Errorf("user-id = %v, want the context value u1", got)
+	}
+	if got := view.Get("trace-id"); got != "t1" {
+		t.Errorf("trace-id = %v, want t1", got)
+	}
+	if got := view.Get("x-request-id"); got != "r1" {
+		t.Errorf("x-request-id = %v, want r1", got)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestTransport(t *testing.T) {
+	var sent http.Header
+	base := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		sent = r.Header
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: r}, nil
+	})
+	tr := NewTransport(base, WithHeaderPrefix("X-Meta-"), WithAllowedKeys("user-id", "region", "authorization"))
+
+	md := Metadata{"user-id": "u1", "secret": "s", "authorization": "Bearer x"}
+	md.Add("region", "eu")
+	md.Add("region", "us")
+	ctx := NewContext(context.Background(), md)
+
+	r, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com", nil)
+	r.Header.Set("X-Meta-User-Id", "stale")
+	if _, err := tr.RoundTrip(r); err != nil {
+		t.Fatal(err)
+	}
+	if got := sent.Values("X-Meta-User-Id"); !slices.Equal(got, []string{"u1"}) {
+		t.Errorf("X-Meta-User-Id = %v, want [u1]", got)
+	}
+	if got := sent.Values("X-Meta-Region"); !slices.Equal(got, []string{"eu", "us"}) {
+		t.Errorf("X-Meta-Region = %v", got)
+	}
+	if sent.Get("X-Meta-Secret") != "" || sent.Get("X-Meta-Authorization") != "" {
+		t.Errorf("headers = %v, want only allowed keys", sent)
+	}
+	if got := r.Header.Get("X-Meta-User-Id"); got != "stale" {
+		t.Errorf("the request was modified: %q", got)
+	}
+}
+
+func TestTransportWithoutMetadata(t *testing.T) {
+	var called bool
+	tr := NewTransport(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		called = true
+		if len(r.Header) != 0 {
+			t.Errorf("headers = %v, want none", r.Header)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: r}, nil
+	}), WithAllowedKeys("*"))
+
+	r, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	if _, err := tr.RoundTrip(r); err != nil || !called {
+		t.Fatalf("round trip = %v, called %v", err, called)
+	}
+}
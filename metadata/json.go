@@ -0,0 +1,289 @@
+package metadata
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/metaitself/xmeta/encoding/json"
+	"google.golang.org/protobuf/types/known/structpb"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// typedValue is the JSON form of the values plain JSON can't tell apart from other types,
+// e.g. {"@type": "duration", "value": "1.5s"} or {"@type": "int64", "value": "5"}.
+type typedValue struct {
+	Type  string `json:"@type"`
+	Value any    `json:"value"`
+}
+
+const (
+	typeValues   = "values"
+	typeStrings  = "[]string"
+	typeDuration = "duration"
+	typeTime     = "time"
+	typeFloat64  = "float64"
+	typeMap      = "map"
+)
+
+// MarshalJSON encodes md as an object with the keys sorted. Strings, bools, ints,
+// fractional float64s, []any and map[string]any values are plain JSON, the others such
+// as the values of a multi-value key, durations, times, []string and the other numeric
+// types are typed objects so UnmarshalJSON restores their type. A map with an "@type"
+// key is wrapped in a {"@type": "map"} object so it isn't read back as a typed value.
+func (m Metadata) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return []byte("null"), nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		b, err := json.Marshal(NormalizeKey(k))
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+		buf.WriteByte(':')
+		if b, err = json.Marshal(jsonValue(m[k])); err != nil {
+			return nil, fmt.Errorf("metadata: marshal key %q: %w", k, err)
+		}
+		buf.Write(b)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func jsonValue(v any) any {
+	switch vv := v.(type) {
+	case multiValue:
+		return typedValue{Type: typeValues, Value: jsonValues(vv)}
+	case []any:
+		return jsonValues(vv)
+	case map[string]any:
+		m := make(map[string]any, len(vv))
+		for k := range vv {
+			m[k] = jsonValue(vv[k])
+		}
+		if _, ok := vv["@type"]; ok {
+			return typedValue{Type: typeMap, Value: m}
+		}
+		return m
+	case []string:
+		return typedValue{Type: typeStrings, Value: vv}
+	case time.Duration:
+		return typedValue{Type: typeDuration, Value: vv.String()}
+	case time.Time:
+		return typedValue{Type: typeTime, Value: vv.Format(time.RFC3339Nano)}
+	case float64:
+		// an integral float64 would read back as an int
+		if vv == math.Trunc(vv) && !math.IsInf(vv, 0) {
+			return typedValue{Type: typeFloat64, Value: strconv.FormatFloat(vv, 'g', -1, 64)}
+		}
+	case int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32:
+		// 64-bit values as strings, structpb numbers are doubles
+		return typedValue{Type: reflect.TypeOf(v).String(), Value: fmt.Sprint(vv)}
+	}
+	return v
+}
+
+func jsonValues(vs []any) []any {
+	out := make([]any, len(vs))
+	for i := range vs {
+		out[i] = jsonValue(vs[i])
+	}
+	return out
+}
+
+// UnmarshalJSON decodes data encoded with MarshalJSON, the keys are added to m. Plain
+// integers become int (int64 when out of range) and other plain numbers float64.
+func (m *Metadata) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw map[string]any
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+	if raw == nil {
+		return nil
+	}
+	if *m == nil {
+		*m = make(Metadata, len(raw))
+	}
+
+	for k, v := range raw {
+		vv, err := fromJSONValue(v)
+		if err != nil {
+			return fmt.Errorf("metadata: unmarshal key %q: %w", k, err)
+		}
+		if mv, ok := vv.(multiValue); ok {
+			for _, v := range mv {
+				m.Add(k, v)
+			}
+			continue
+		}
+		m.Set(k, vv)
+	}
+	return nil
+}
+
+func fromJSONValue(v any) (any, error) {
+	switch vv := v.(type) {
+	case json.Number:
+		if i, err := vv.Int64(); err == nil {
+			if i >= math.MinInt && i <= math.MaxInt {
+				return int(i), nil
+			}
+			return i, nil
+		}
+		return vv.Float64()
+	case []any:
+		return fromJSONValues(vv)
+	case map[string]any:
+		if t, ok := vv["@type"].(string); ok && len(vv) == 2 {
+			if tv, ok := vv["value"]; ok {
+				return fromTypedValue(t, tv)
+			}
+		}
+		return fromJSONMap(vv)
+	}
+	return v, nil
+}
+
+func fromJSONMap(vv map[string]any) (map[string]any, error) {
+	m := make(map[string]any, len(vv))
+	for k := range vv {
+		var err error
+		if m[k], err = fromJSONValue(vv[k]); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func fromJSONValues(vs []any) ([]any, error) {
+	out := make([]any, len(vs))
+	for i := range vs {
+		var err error
+		if out[i], err = fromJSONValue(vs[i]); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func fromTypedValue(t string, v any) (any, error) {
+	switch t {
+	case typeValues:
+		vs, ok := v.([]any)
+		if !ok {
+			break
+		}
+		mv, err := fromJSONValues(vs)
+		return multiValue(mv), err
+	case typeStrings:
+		vs, ok := v.([]any)
+		if !ok {
+			break
+		}
+		ss := make([]string, len(vs))
+		for i := range vs {
+			if ss[i], ok = vs[i].(string); !ok {
+				return nil, fmt.Errorf("metadata: invalid %s element %v", t, vs[i])
+			}
+		}
+		return ss, nil
+	case typeMap:
+		m, ok := v.(map[string]any)
+		if !ok {
+			break
+		}
+		return fromJSONMap(m)
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("metadata: invalid %s value %v", t, v)
+	}
+	switch t {
+	case typeDuration:
+		return time.ParseDuration(s)
+	case typeTime:
+		return time.Parse(time.RFC3339Nano, s)
+	case typeFloat64:
+		return strconv.ParseFloat(s, 64)
+	case "float32":
+		f, err := strconv.ParseFloat(s, 32)
+		return float32(f), err
+	case "int8", "int16", "int32", "int64":
+		i, err := strconv.ParseInt(s, 10, intBits(t))
+		return reflect.ValueOf(i).Convert(numericTypes[t]).Interface(), err
+	case "uint", "uint8", "uint16", "uint32", "uint64":
+		u, err := strconv.ParseUint(s, 10, intBits(t))
+		return reflect.ValueOf(u).Convert(numericTypes[t]).Interface(), err
+	}
+	return nil, fmt.Errorf("metadata: unknown value type %q", t)
+}
+
+var numericTypes = map[string]reflect.Type{
+	"int8":   reflect.TypeOf(int8(0)),
+	"int16":  reflect.TypeOf(int16(0)),
+	"int32":  reflect.TypeOf(int32(0)),
+	"int64":  reflect.TypeOf(int64(0)),
+	"uint":   reflect.TypeOf(uint(0)),
+	"uint8":  reflect.TypeOf(uint8(0)),
+	"uint16": reflect.TypeOf(uint16(0)),
+	"uint32": reflect.TypeOf(uint32(0)),
+	"uint64": reflect.TypeOf(uint64(0)),
+}
+
+// intBits returns the bit size of an integer type name, 0 for int and uint.
+func intBits(t string) int {
+	for _, n := range []int{8, 16, 32, 64} {
+		if strings.HasSuffix(t, strconv.Itoa(n)) {
+			return n
+		}
+	}
+	return 0
+}
+
+// ToProtoStruct converts md to a structpb.Struct with the JSON form of MarshalJSON,
+// structpb numbers are doubles so int values beyond 2^53 lose precision.
+func (m Metadata) ToProtoStruct() (*structpb.Struct, error) {
+	b, err := m.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	s := &structpb.Struct{}
+	if err = s.UnmarshalJSON(b); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// FromProtoStruct creates md from a structpb.Struct built by ToProtoStruct.
+func FromProtoStruct(s *structpb.Struct) (Metadata, error) {
+	md := Metadata{}
+	if s == nil {
+		return md, nil
+	}
+	b, err := s.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	if err = md.UnmarshalJSON(b); err != nil {
+		return nil, err
+	}
+	return md, nil
+}
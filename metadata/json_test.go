@@ -0,0 +1,100 @@
+package metadata
+
+import (
+	"github.com/metaitself/xmeta/encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newRoundTripMetadata() Metadata {
+	md := New(map[string]any{
+		"string":   "x",
+		"bool":     true,
+		"int":      1,
+		"int8":     int8(-8),
+		"int64":    int64(1) << 60,
+		"uint64":   uint64(1) << 63,
+		"float32":  float32(1.5),
+		"float64":  2.0,
+		"fraction": 2.5,
+		"duration": 1500 * time.Millisecond,
+		"time":     time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC),
+		"roles":    []string{"a", "b"},
+		"list":     []any{"a", 1},
+		"map":      map[string]any{"k": int64(2), "d": time.Second},
+		// user maps which look like typed values
+		"typed":    map[string]any{"@type": "duration", "value": "1s"},
+		"envelope": map[string]any{"@type": "map", "value": map[string]any{"@type": "x"}},
+		"nested":   map[string]any{"inner": map[string]any{"@type": "int64", "value": "5"}},
+	})
+	md.Add("tag", "a")
+	md.Add("tag", 2)
+	return md
+}
+
+func TestMetadataJSONRoundTrip(t *testing.T) {
+	md := newRoundTripMetadata()
+
+	b, err := json.Marshal(md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Metadata
+	if err = json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, md) {
+		t.Fatalf("round trip mismatch\n got: %#v\nwant: %#v\njson: %s", got, md, b)
+	}
+
+	if roles, _ := got.Get("roles").([]string); len(roles) != 2 {
+		t.Errorf("Get(roles) = %#v, want the []string value", got.Get("roles"))
+	}
+	if vs := got.Values("tag"); len(vs) != 2 {
+		t.Errorf("Values(tag) = %#v, want 2 values", vs)
+	}
+}
+
+func TestMetadataJSONStableOrder(t *testing.T) {
+	md := newRoundTripMetadata()
+	first, err := json.Marshal(md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		b, _ := json.Marshal(md.Clone())
+		if string(b) != string(first) {
+			t.Fatalf("unstable encoding\n%s\n%s", first, b)
+		}
+	}
+}
+
+func TestMetadataJSONPlainValues(t *testing.T) {
+	var md Metadata
+	if err := json.Unmarshal([]byte(`{"I":3,"f":1.25,"big":9223372036854775807,"s":"x","a":[1,"y"]}`), &md); err != nil {
+		t.Fatal(err)
+	}
+	want := Metadata{"i": 3, "f": 1.25, "big": 9223372036854775807, "s": "x", "a": []any{1, "y"}}
+	if !reflect.DeepEqual(md, want) {
+		t.Fatalf("got %#v, want %#v", md, want)
+	}
+}
+
+func TestMetadataProtoStructRoundTrip(t *testing.T) {
+	md := newRoundTripMetadata()
+	// structpb numbers are doubles
+	md.Set("int", 1<<40)
+
+	s, err := md.ToProtoStruct()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := FromProtoStruct(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, md) {
+		t.Fatalf("round trip mismatch\n got: %#v\nwant: %#v", got, md)
+	}
+}
@@ -0,0 +1,67 @@
+package metadata
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// KeyValidator rejects a canonical key by returning an error.
+type KeyValidator func(key string) error
+
+var (
+	validatorsMu  sync.RWMutex
+	keyValidators []KeyValidator
+)
+
+// NormalizeKey returns the canonical form of key, every entry point of Metadata applies it.
+func NormalizeKey(key string) string {
+	return strings.ToLower(strings.TrimSpace(key))
+}
+
+// RegisterKeyValidator adds validators run on every key stored in a Metadata,
+// keys rejected by a validator are dropped, or reported by SetE.
+func RegisterKeyValidator(validators ...KeyValidator) {
+	validatorsMu.Lock()
+	defer validatorsMu.Unlock()
+
+	keyValidators = append(keyValidators, validators...)
+}
+
+// ValidateKey normalizes key and runs the registered validators on it.
+func ValidateKey(key string) (string, error) {
+	key = NormalizeKey(key)
+	if key == "" {
+		return "", fmt.Errorf("metadata: empty key")
+	}
+
+	validatorsMu.RLock()
+	defer validatorsMu.RUnlock()
+
+	for _, v := range keyValidators {
+		if err := v(key); err != nil {
+			return "", err
+		}
+	}
+	return key, nil
+}
+
+// ValidateHeaderKey accepts keys valid as both HTTP header names (RFC 7230 tokens)
+// and gRPC metadata keys, which also excludes the reserved "grpc-" prefix.
+func ValidateHeaderKey(key string) error {
+	if strings.HasPrefix(key, "grpc-") {
+		return fmt.Errorf("metadata: key %q uses the reserved grpc- prefix", key)
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c >= 'a' && c <= 'z' || c >= '0' && c <= '9' {
+			continue
+		}
+		switch c {
+		case '-', '_', '.':
+			continue
+		}
+		return fmt.Errorf("metadata: invalid character %q in key %q", c, key)
+	}
+	return nil
+}
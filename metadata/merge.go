@@ -0,0 +1,67 @@
+package metadata
+
+import "context"
+
+type deleted struct{}
+
+// Deleted is the tombstone value removing a key when merged, e.g.
+// MergeContext(ctx, Metadata{"user-id": Deleted}, true). It is resolved like any other
+// patch value, so KeepExisting keeps an existing key while Overwrite and Append remove
+// it. An empty string is a regular value.
+var Deleted any = deleted{}
+
+// MergeFunc resolves the value of a key present in both the existing metadata and the
+// patch, patch may be Deleted. Returning Deleted removes the key, nil keeps the existing value.
+type MergeFunc func(key string, existing, patch any) any
+
+var (
+	// KeepExisting keeps the existing value.
+	KeepExisting MergeFunc = func(_ string, existing, _ any) any { return existing }
+	// Overwrite replaces the existing value with the patch value.
+	Overwrite MergeFunc = func(_ string, _, patch any) any { return patch }
+	// Append adds the patch values to the existing values, see Add.
+	Append MergeFunc = func(_ string, existing, patch any) any {
+		if patch == Deleted {
+			return Deleted
+		}
+		vs := append(values(existing), values(patch)...)
+		if len(vs) == 1 {
+			return vs[0]
+		}
+		return multiValue(vs)
+	}
+)
+
+// Merge returns a deep copy of m with a copy of patch merged in, see Clone. resolve is
+// called for the keys present in both, a Deleted patch value of a missing key is dropped.
+// Nil patch values are ignored like with Set, and invalid keys are skipped.
+func (m Metadata) Merge(patch Metadata, resolve MergeFunc) Metadata {
+	md := m.Clone()
+	for k, v := range patch {
+		var err error
+		if k, err = ValidateKey(k); err != nil || v == nil {
+			continue
+		}
+		v = cloneValue(v)
+		if cur, ok := md[k]; ok {
+			v = resolve(k, cur, v)
+		}
+		if v == Deleted {
+			delete(md, k)
+		} else if v != nil {
+			md[k] = v
+		}
+	}
+	return md
+}
+
+// MergeContextWith returns a new context whose metadata is the existing one with patchMd
+// merged in with resolve, see Merge.
+func MergeContextWith(ctx context.Context, patchMd Metadata, resolve MergeFunc) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	md, _ := ctx.Value(metadataContextKey{}).(Metadata)
+	return context.WithValue(ctx, metadataContextKey{}, md.Merge(patchMd, resolve))
+}
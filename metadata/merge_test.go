@@ -0,0 +1,116 @@
+package metadata
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	base := Metadata{"a": "1", "b": "2", "c": "3"}
+	patch := Metadata{
+		"a": "patched",
+		"b": Deleted,
+		"d": "new",
+		"e": Deleted,
+		"f": nil,
+		"":  "x",
+	}
+
+	tests := []struct {
+		name    string
+		resolve MergeFunc
+		want    Metadata
+	}{
+		{
+			name:    "keep existing",
+			resolve: KeepExisting,
+			want:    Metadata{"a": "1", "b": "2", "c": "3", "d": "new"},
+		},
+		{
+			name:    "overwrite",
+			resolve: Overwrite,
+			want:    Metadata{"a": "patched", "c": "3", "d": "new"},
+		},
+		{
+			name:    "append",
+			resolve: Append,
+			want:    Metadata{"a": multiValue{"1", "patched"}, "c": "3", "d": "new"},
+		},
+		{
+			name: "custom",
+			resolve: func(key string, existing, patch any) any {
+				switch key {
+				case "a":
+					return existing.(string) + patch.(string)
+				case "c":
+					return Deleted
+				}
+				// keep the existing value, the tombstone of b included
+				return nil
+			},
+			want: Metadata{"a": "1patched", "b": "2", "c": "3", "d": "new"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := base.Merge(patch, tt.resolve)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Merge = %#v, want %#v", got, tt.want)
+			}
+			if !reflect.DeepEqual(base, Metadata{"a": "1", "b": "2", "c": "3"}) {
+				t.Fatalf("Merge changed the receiver: %#v", base)
+			}
+		})
+	}
+}
+
+func TestMergeEmptyStringIsAValue(t *testing.T) {
+	got := Metadata{"a": "1"}.Merge(Metadata{"a": "", "b": ""}, Overwrite)
+	if want := (Metadata{"a": "", "b": ""}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Merge = %#v, want %#v", got, want)
+	}
+}
+
+func TestMergeAppendMultiValue(t *testing.T) {
+	base := Metadata{}
+	base.Add("tag", "a")
+	base.Add("tag", "b")
+	patch := Metadata{}
+	patch.Add("tag", "c")
+	patch.Add("tag", "d")
+
+	got := base.Merge(patch, Append)
+	if vs := got.Values("tag"); !reflect.DeepEqual(vs, []any{"a", "b", "c", "d"}) {
+		t.Fatalf("Values = %v", vs)
+	}
+	if vs := base.Values("tag"); len(vs) != 2 {
+		t.Fatalf("Append changed the receiver: %v", vs)
+	}
+}
+
+func TestMergeContext(t *testing.T) {
+	ctx := NewContext(context.Background(), Metadata{"user-id": "u1", "trace-id": "t1"})
+
+	kept := MergeContext(ctx, Metadata{"user-id": Deleted, "trace-id": "t2", "new": "n"}, false)
+	md, _ := FromContext(kept)
+	if want := (Metadata{"user-id": "u1", "trace-id": "t1", "new": "n"}); !reflect.DeepEqual(md, want) {
+		t.Fatalf("MergeContext without overwrite = %#v, want %#v", md, want)
+	}
+
+	overwritten := MergeContext(ctx, Metadata{"user-id": Deleted, "trace-id": "t2"}, true)
+	md, _ = FromContext(overwritten)
+	if want := (Metadata{"trace-id": "t2"}); !reflect.DeepEqual(md, want) {
+		t.Fatalf("MergeContext with overwrite = %#v, want %#v", md, want)
+	}
+
+	md, _ = FromContext(ctx)
+	if md.Get("user-id") != "u1" || md.Get("trace-id") != "t1" {
+		t.Fatalf("the parent context changed: %#v", md)
+	}
+
+	appended := MergeContextWith(context.Background(), Metadata{"tag": "a"}, Append)
+	if md, _ = FromContext(appended); md.Get("tag") != "a" {
+		t.Fatalf("MergeContextWith without metadata = %#v", md)
+	}
+}
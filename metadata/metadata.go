@@ -2,105 +2,258 @@ package metadata
 
 import (
 	"context"
-	"strings"
+	"fmt"
+	"maps"
+	"slices"
 )
 
 type Metadata map[string]any
 
+// multiValue holds the values of a key added more than once, it is never
+// appended to in place so copies of a Metadata don't share values.
+type multiValue []any
+
 // New creates md from a given key-values map.
 func New(mds ...map[string]any) Metadata {
 	md := Metadata{}
 	for _, m := range mds {
 		for k, v := range m {
-			md[k] = v
+			md.Set(k, v)
 		}
 	}
 	return md
 }
 
-// Get returns the value associated with the passed key.
+// Get returns the value associated with the passed key, the first one if the key has several.
 func (m Metadata) Get(key string) any {
-	return m[strings.ToLower(key)]
+	return first(m[NormalizeKey(key)])
 }
 
-// Set stores the key-value pair.
+func first(v any) any {
+	if mv, ok := v.(multiValue); ok {
+		if len(mv) == 0 {
+			return nil
+		}
+		return mv[0]
+	}
+	return v
+}
+
+// Set stores the key-value pair, invalid keys and nil values are ignored.
 func (m Metadata) Set(key string, value any) {
-	if key == "" || value == nil {
-		return
+	_ = m.SetE(key, value)
+}
+
+// SetE stores the key-value pair, replacing every previous value, and reports why an
+// invalid key was rejected.
+func (m Metadata) SetE(key string, value any) error {
+	key, err := ValidateKey(key)
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		return nil
+	}
+
+	m[key] = value
+	return nil
+}
+
+// Add appends value to the values of key.
+func (m Metadata) Add(key string, value any) {
+	_ = m.AddE(key, value)
+}
+
+// AddE appends value to the values of key and reports why an invalid key was rejected.
+func (m Metadata) AddE(key string, value any) error {
+	key, err := ValidateKey(key)
+	if err != nil {
+		return err
 	}
+	if value == nil {
+		return nil
+	}
+
+	switch cur := m[key].(type) {
+	case nil:
+		m[key] = value
+	case multiValue:
+		m[key] = append(cur[:len(cur):len(cur)], value)
+	default:
+		m[key] = multiValue{cur, value}
+	}
+	return nil
+}
 
-	m[strings.ToLower(key)] = value
+// Del deletes the values of key.
+func (m Metadata) Del(key string) {
+	delete(m, NormalizeKey(key))
 }
 
 func (m Metadata) IsExists(key string) bool {
-	_, ok := m[key]
+	_, ok := m[NormalizeKey(key)]
 	return ok
 }
 
-// Range iterate over element in metadata.
+// Validate checks every key is canonical and accepted by the registered validators.
+func (m Metadata) Validate() error {
+	for k := range m {
+		nk, err := ValidateKey(k)
+		if err != nil {
+			return err
+		}
+		if nk != k {
+			return fmt.Errorf("metadata: key %q isn't canonical", k)
+		}
+	}
+	return nil
+}
+
+// Range iterate over element in metadata, keys are passed in canonical form
+// with their first value.
 func (m Metadata) Range(f func(k string, v any) bool) {
 	for k, v := range m {
-		if !f(k, v) {
+		if !f(NormalizeKey(k), first(v)) {
+			break
+		}
+	}
+}
+
+// RangeValues iterate over element in metadata with all the values of each key.
+func (m Metadata) RangeValues(f func(k string, vs []any) bool) {
+	for k, v := range m {
+		if !f(NormalizeKey(k), values(v)) {
 			break
 		}
 	}
 }
 
 // Values returns a slice of values associated with the passed key.
-func (m Metadata) Values(key string) any {
-	return m[strings.ToLower(key)]
+func (m Metadata) Values(key string) []any {
+	return values(m[NormalizeKey(key)])
+}
+
+func values(v any) []any {
+	switch vv := v.(type) {
+	case nil:
+		return nil
+	case multiValue:
+		return append([]any(nil), vv...)
+	}
+	return []any{v}
 }
 
-// Clone returns a deep copy of Metadata
+// Clone returns a deep copy of Metadata, slices and maps of the values are copied while
+// other reference values such as pointers are shared.
 func (m Metadata) Clone() Metadata {
 	md := make(Metadata, len(m))
 	for k, v := range m {
-		md[k] = v
+		md[k] = cloneValue(v)
 	}
 
 	return md
 }
 
+// cloneValue copies the slices and maps of v, recursively for []any and map[string]any.
+func cloneValue(v any) any {
+	switch vv := v.(type) {
+	case multiValue:
+		return multiValue(cloneValues(vv))
+	case []any:
+		return cloneValues(vv)
+	case map[string]any:
+		if vv == nil {
+			return vv
+		}
+		m := make(map[string]any, len(vv))
+		for k := range vv {
+			m[k] = cloneValue(vv[k])
+		}
+		return m
+	case []string:
+		return slices.Clone(vv)
+	case []byte:
+		return slices.Clone(vv)
+	case map[string]string:
+		return maps.Clone(vv)
+	}
+	return v
+}
+
+func cloneValues(vs []any) []any {
+	if vs == nil {
+		return nil
+	}
+	out := make([]any, len(vs))
+	for i := range vs {
+		out[i] = cloneValue(vs[i])
+	}
+	return out
+}
+
 func (m Metadata) FromStrMap(f map[string]string) {
 	for k, v := range f {
-		m[strings.ToLower(k)] = v
+		m.Set(k, v)
 	}
 }
 
 type metadataContextKey struct{}
 
-// NewContext creates a new context with metadata attached.
+// NewContext creates a new context with a snapshot of md attached, later changes
+// to md don't affect the context, see Clone. Keys which aren't canonical are normalized.
 func NewContext(ctx context.Context, md Metadata) context.Context {
-	return context.WithValue(ctx, metadataContextKey{}, md)
+	snapshot := make(Metadata, len(md))
+	for k, v := range md {
+		snapshot.Set(k, cloneValue(v))
+	}
+	return context.WithValue(ctx, metadataContextKey{}, snapshot)
 }
 
-// FromContext returns metadata from the given context.
+// FromContext returns a copy of the metadata of the given context, changing it doesn't
+// affect the context, use MergeContext or AppendToContext to derive a new one.
+// ViewFromContext avoids the copy for read-only access.
 func FromContext(ctx context.Context) (Metadata, bool) {
 	md, ok := ctx.Value(metadataContextKey{}).(Metadata)
-	return md, ok
+	if !ok {
+		return nil, false
+	}
+	return md.Clone(), true
+}
+
+// ViewFromContext returns a read-only view of the metadata of the given context.
+func ViewFromContext(ctx context.Context) (View, bool) {
+	md, ok := ctx.Value(metadataContextKey{}).(Metadata)
+	return View{md: md}, ok
 }
 
 // MergeContext merges metadata to existing metadata, overwriting if specified.
+// Keys whose patch value is Deleted are removed when overwriting, without overwrite
+// existing keys are kept, see Deleted and MergeContextWith.
 func MergeContext(ctx context.Context, patchMd Metadata, overwrite bool) context.Context {
+	if overwrite {
+		return MergeContextWith(ctx, patchMd, Overwrite)
+	}
+	return MergeContextWith(ctx, patchMd, KeepExisting)
+}
+
+// AppendToContext returns a new context with the key-value pairs of kv added to the
+// values of its metadata, see Add. It panics if kv has an odd length or a key isn't a string.
+func AppendToContext(ctx context.Context, kv ...any) context.Context {
+	if len(kv)%2 == 1 {
+		panic(fmt.Sprintf("metadata: AppendToContext got an odd number of input pairs: %d", len(kv)))
+	}
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
 	md, _ := ctx.Value(metadataContextKey{}).(Metadata)
-
-	cmd := make(Metadata, len(md))
-	for k, v := range md {
-		cmd[k] = v
-	}
-
-	for k, v := range patchMd {
-		if _, ok := cmd[k]; ok && !overwrite {
-			// skip
-		} else if v != "" {
-			cmd[k] = v
-		} else {
-			delete(cmd, k)
+	cmd := md.Clone()
+	for i := 0; i < len(kv); i += 2 {
+		k, ok := kv[i].(string)
+		if !ok {
+			panic(fmt.Sprintf("metadata: AppendToContext key %v isn't a string", kv[i]))
 		}
+		cmd.Add(k, cloneValue(kv[i+1]))
 	}
 
 	return context.WithValue(ctx, metadataContextKey{}, cmd)
@@ -0,0 +1,69 @@
+package metadata
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestCloneDeepCopy(t *testing.T) {
+	md := Metadata{
+		"roles":  []string{"a"},
+		"list":   []any{"x", []string{"y"}},
+		"nested": map[string]any{"k": []any{1}},
+		"labels": map[string]string{"env": "prod"},
+		"raw":    []byte("b"),
+	}
+	md.Add("tag", []string{"t"})
+	md.Add("tag", "u")
+
+	c := md.Clone()
+	if !reflect.DeepEqual(c, md) {
+		t.Fatalf("clone = %#v, want %#v", c, md)
+	}
+
+	c["roles"].([]string)[0] = "changed"
+	c["list"].([]any)[1].([]string)[0] = "changed"
+	c["nested"].(map[string]any)["k"].([]any)[0] = "changed"
+	c["labels"].(map[string]string)["env"] = "changed"
+	c["raw"].([]byte)[0] = 'c'
+	c["tag"].(multiValue)[0].([]string)[0] = "changed"
+
+	want := Metadata{
+		"roles":  []string{"a"},
+		"list":   []any{"x", []string{"y"}},
+		"nested": map[string]any{"k": []any{1}},
+		"labels": map[string]string{"env": "prod"},
+		"raw":    []byte("b"),
+		"tag":    multiValue{[]string{"t"}, "u"},
+	}
+	if !reflect.DeepEqual(md, want) {
+		t.Fatalf("changing the clone changed the original: %#v", md)
+	}
+}
+
+func TestContextSnapshot(t *testing.T) {
+	roles := []string{"a"}
+	ctx := NewContext(context.Background(), Metadata{"roles": roles})
+	roles[0] = "changed"
+
+	md, _ := FromContext(ctx)
+	if got := md.Get("roles").([]string)[0]; got != "a" {
+		t.Fatalf("NewContext kept a reference to the caller's slice: %q", got)
+	}
+	md.Get("roles").([]string)[0] = "changed"
+
+	view, _ := ViewFromContext(ctx)
+	if got := view.Get("roles").([]string)[0]; got != "a" {
+		t.Fatalf("changing FromContext changed the context: %q", got)
+	}
+
+	patch := []string{"p"}
+	ctx = MergeContext(ctx, Metadata{"patch": patch}, true)
+	ctx = AppendToContext(ctx, "extra", patch)
+	patch[0] = "changed"
+	view, _ = ViewFromContext(ctx)
+	if view.Get("patch").([]string)[0] != "p" || view.Get("extra").([]string)[0] != "p" {
+		t.Fatal("the merged values share the caller's slice")
+	}
+}
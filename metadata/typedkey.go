@@ -0,0 +1,53 @@
+package metadata
+
+import (
+	"context"
+	"fmt"
+)
+
+// Key is a metadata key whose value has type T, declare it once and share it:
+//
+//	var UserID = metadata.NewKey[int64]("user-id")
+type Key[T any] struct {
+	name string
+}
+
+// NewKey returns a typed key, it panics if name isn't a valid metadata key.
+func NewKey[T any](name string) Key[T] {
+	k, err := ValidateKey(name)
+	if err != nil {
+		panic(err)
+	}
+	return Key[T]{name: k}
+}
+
+// Name returns the normalized key.
+func (k Key[T]) Name() string {
+	return k.name
+}
+
+func (k Key[T]) String() string {
+	return k.name
+}
+
+// Get returns the value of the key in the metadata of ctx, converted to T.
+func (k Key[T]) Get(ctx context.Context) (T, bool) {
+	view, _ := ViewFromContext(ctx)
+	v, err := Get[T](view.md, k.name)
+	return v, err == nil
+}
+
+// MustGet is like Get but panics if the key is missing or can't be converted to T.
+func (k Key[T]) MustGet(ctx context.Context) T {
+	view, _ := ViewFromContext(ctx)
+	v, err := Get[T](view.md, k.name)
+	if err != nil {
+		panic(fmt.Errorf("metadata: get %s: %w", k.name, err))
+	}
+	return v
+}
+
+// Set returns a copy of ctx whose metadata holds v for the key.
+func (k Key[T]) Set(ctx context.Context, v T) context.Context {
+	return MergeContext(ctx, Metadata{k.name: v}, true)
+}
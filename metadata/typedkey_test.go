@@ -0,0 +1,65 @@
+package metadata
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestKey(t *testing.T) {
+	userID := NewKey[int64]("User-ID")
+	timeout := NewKey[time.Duration]("timeout")
+	if userID.Name() != "user-id" {
+		t.Fatalf("Name = %q, want the normalized key", userID.Name())
+	}
+
+	ctx := context.Background()
+	if _, ok := userID.Get(ctx); ok {
+		t.Fatal("Get found a key without metadata")
+	}
+
+	ctx = userID.Set(ctx, 42)
+	ctx = timeout.Set(ctx, 2*time.Second)
+	if v, ok := userID.Get(ctx); !ok || v != 42 {
+		t.Fatalf("Get = %v, %v, want 42", v, ok)
+	}
+	if v := timeout.MustGet(ctx); v != 2*time.Second {
+		t.Fatalf("MustGet = %v, want 2s", v)
+	}
+	ctx = userID.Set(ctx, 7)
+	if v := userID.MustGet(ctx); v != 7 {
+		t.Fatalf("Set didn't overwrite: %v", v)
+	}
+
+	// values set untyped are converted
+	ctx = AppendToContext(context.Background(), "user-id", "43", "timeout", "1.5")
+	if v, ok := userID.Get(ctx); !ok || v != 43 {
+		t.Fatalf("Get = %v, %v, want 43", v, ok)
+	}
+	if v, ok := timeout.Get(ctx); !ok || v != 1500*time.Millisecond {
+		t.Fatalf("Get = %v, %v, want 1.5s", v, ok)
+	}
+
+	ctx = AppendToContext(context.Background(), "user-id", "not a number")
+	if _, ok := userID.Get(ctx); ok {
+		t.Fatal("Get converted an invalid value")
+	}
+}
+
+func TestKeyMustGetPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("MustGet didn't panic on a missing key")
+		}
+	}()
+	NewKey[string]("missing").MustGet(context.Background())
+}
+
+func TestNewKeyPanicsOnInvalidName(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("NewKey didn't panic on an empty name")
+		}
+	}()
+	NewKey[string]("")
+}
@@ -0,0 +1,42 @@
+package metadata
+
+// View is a read-only view of the metadata attached to a context, it is safe to
+// share across goroutines. The values aren't copied, slices and maps returned by Get
+// must not be modified, use Metadata for a copy.
+type View struct {
+	md Metadata
+}
+
+// Get returns the value associated with the passed key, the first one if the key has several.
+func (v View) Get(key string) any {
+	return v.md.Get(key)
+}
+
+// Values returns a slice of values associated with the passed key.
+func (v View) Values(key string) []any {
+	return v.md.Values(key)
+}
+
+func (v View) IsExists(key string) bool {
+	return v.md.IsExists(key)
+}
+
+// Len returns the number of keys.
+func (v View) Len() int {
+	return len(v.md)
+}
+
+// Range iterate over element in metadata with the first value of each key.
+func (v View) Range(f func(k string, v any) bool) {
+	v.md.Range(f)
+}
+
+// RangeValues iterate over element in metadata with all the values of each key.
+func (v View) RangeValues(f func(k string, vs []any) bool) {
+	v.md.RangeValues(f)
+}
+
+// Metadata returns a mutable copy of the view.
+func (v View) Metadata() Metadata {
+	return v.md.Clone()
+}